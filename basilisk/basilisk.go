@@ -3,24 +3,30 @@ package main
 import (
 	"github.com/ScarletTanager/basilisk/basilisk/handlers"
 	"github.com/ScarletTanager/basilisk/basilisk/model"
-	"github.com/ScarletTanager/basilisk/classifiers/knn"
 	"github.com/labstack/echo/v4"
 )
 
-var currentModel *knn.KNearestNeighborClassifier
-
 func main() {
 	rm := &model.RunningModels{}
 	e := echo.New()
 
 	e.POST("/models", handlers.CreateModelHandler(rm), handlers.CheckContentTypeMiddleware(handlers.AllowedHeaders{echo.MIMEApplicationJSON}))
 	e.GET("/models", handlers.ListModelsHandler(rm))
+	e.GET("/models/distance_methods", handlers.ListDistanceMethodsHandler)
 	e.POST("/datasets", handlers.CreateDatasetHandler, handlers.CheckContentTypeMiddleware(handlers.AllowedHeaders{echo.MIMEApplicationJSON}))
 
 	modelGroup := e.Group("/models/:id", handlers.RetrieveModelMiddleware(rm))
 	modelGroup.PUT("/data", handlers.TrainModelHandler(rm), handlers.CheckContentTypeMiddleware(handlers.AllowedHeaders{echo.MIMEApplicationJSON, "text/csv"}))
+	modelGroup.POST("/data", handlers.AddDataHandler(rm), handlers.CheckContentTypeMiddleware(handlers.AllowedHeaders{echo.MIMEApplicationJSON, "text/csv"}))
 	modelGroup.GET("/results", handlers.TestModelHandler(rm))
 	modelGroup.GET("/results/details", handlers.TestResultsDetailsHandler(rm))
+	modelGroup.GET("/results/curves", handlers.CurvesHandler(rm))
+	modelGroup.GET("/tree", handlers.TreeHandler(rm))
+	modelGroup.GET("/forest", handlers.ForestHandler(rm))
+	modelGroup.GET("/coefficients", handlers.CoefficientsHandler(rm))
+	modelGroup.GET("/index/recall", handlers.IndexRecallHandler(rm))
+	modelGroup.POST("/crossvalidate", handlers.CrossValidateHandler(rm), handlers.CheckContentTypeMiddleware(handlers.AllowedHeaders{echo.MIMEApplicationJSON}))
+	modelGroup.POST("/predictions", handlers.PredictHandler(rm), handlers.CheckContentTypeMiddleware(handlers.AllowedHeaders{echo.MIMEApplicationJSON}))
 
 	e.Logger.Fatal(e.Start(":9323"))
 }
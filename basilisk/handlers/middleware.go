@@ -13,6 +13,8 @@ import (
 const (
 	ParamModelID = "id"
 
+	QueryParamExplain = "explain"
+
 	ContextKeyModel = "model"
 )
 
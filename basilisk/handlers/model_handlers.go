@@ -1,12 +1,16 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/ScarletTanager/basilisk/basilisk/model"
 	"github.com/ScarletTanager/basilisk/classifiers"
+	"github.com/ScarletTanager/basilisk/crossvalidation"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/gommon/log"
 )
@@ -53,6 +57,12 @@ func ListModelsHandler(rm *model.RunningModels) echo.HandlerFunc {
 	}
 }
 
+// ListDistanceMethodsHandler returns an echo.HandlerFunc which lists the distance methods
+// which can be used by KNN models, including any registered by the server
+func ListDistanceMethodsHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, classifiers.DistanceMethods())
+}
+
 // CreateModelHandler returns an echo.HandlerFunc configured to set the currentModel with a valid request
 func CreateModelHandler(rm *model.RunningModels) echo.HandlerFunc {
 	return func(c echo.Context) error {
@@ -68,7 +78,7 @@ func CreateModelHandler(rm *model.RunningModels) echo.HandlerFunc {
 
 		log.Infof("Model configuration: %v", mc)
 
-		if classifier, err := classifiers.NewKnn(mc.K, mc.DistanceMethod); err != nil {
+		if classifier, err := mc.NewClassifier(); err != nil {
 			return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: "Invalid model configuration", Error: err})
 		} else {
 			if id, err := rm.Add(classifier); err != nil {
@@ -89,43 +99,93 @@ func TrainModelHandler(rm *model.RunningModels) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		var (
 			err error
-			raw classifiers.DataSet
 			ds  *classifiers.DataSet
 		)
 
 		if knnc, ok := c.Get(ContextKeyModel).(classifiers.Classifier); !ok {
 			return c.JSON(http.StatusNotFound, &model.ModelsError{Message: "Model not found"})
 		} else {
-			// Check what data format is being sent
-			switch c.Request().Header.Get(echo.HeaderContentType) {
-			case echo.MIMEApplicationJSON:
-				if err = c.Bind(&raw); err != nil {
-					return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: "Cannot parse body"})
-				}
+			if ds, err = bindDataSet(c); err != nil {
+				return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: err.Error()})
+			}
 
-				if ds, err = classifiers.NewDataSet(raw.ClassNames, raw.AttributeNames, raw.Records); err != nil {
-					return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: fmt.Sprintf("Invalid data: %s", err.Error())})
-				}
-			case "text/csv":
-				bodyBytes, err := io.ReadAll(c.Request().Body)
-				if err != nil {
-					// Probably not really what we want here, but...whatever
-					return c.JSON(http.StatusBadRequest, "Unable to read body")
+			// Training only fails if the data does not suit the model, e.g. it has too few
+			// records or lacks a weighted attribute, and leaves the model as it was
+			if err = knnc.TrainFromDataset(ds, nil); err != nil {
+				return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: err.Error()})
+			}
+		}
+
+		return c.JSON(http.StatusOK, `{"Message": "Completed"}`)
+	}
+}
+
+// AddDataHandler returns an echo.HandlerFunc which adds the records in the request body to
+// the training data of a trained KNN model, without retraining it
+func AddDataHandler(rm *model.RunningModels) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var (
+			err error
+			ds  *classifiers.DataSet
+		)
+
+		if cl, ok := c.Get(ContextKeyModel).(classifiers.Classifier); !ok {
+			return c.JSON(http.StatusNotFound, &model.ModelsError{Message: "Model not found"})
+		} else {
+			if knnc, ok := cl.(*classifiers.KNearestNeighborClassifier); !ok {
+				return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: fmt.Sprintf("%s models cannot add records without retraining", cl.Type())})
+			} else {
+				if ds, err = bindDataSet(c); err != nil {
+					return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: err.Error()})
 				}
-				if ds, err = classifiers.FromCSV(bodyBytes); err != nil || ds == nil {
+
+				if err = knnc.AddDataset(ds); err != nil {
 					return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: fmt.Sprintf("Invalid data: %s", err.Error())})
 				}
 			}
-
-			if err = knnc.TrainFromDataset(ds, nil); err != nil {
-				return c.JSON(http.StatusInternalServerError, &model.ModelsError{Message: "Error training model, please retry"})
-			}
 		}
 
 		return c.JSON(http.StatusOK, `{"Message": "Completed"}`)
 	}
 }
 
+// bindDataSet parses the dataset in the (JSON or CSV) request body
+func bindDataSet(c echo.Context) (*classifiers.DataSet, error) {
+	var (
+		err error
+		raw classifiers.DataSet
+		ds  *classifiers.DataSet
+	)
+
+	// Check what data format is being sent
+	switch contentType := c.Request().Header.Get(echo.HeaderContentType); contentType {
+	case echo.MIMEApplicationJSON:
+		if err = c.Bind(&raw); err != nil {
+			return nil, errors.New("Cannot parse body")
+		}
+
+		if ds, err = classifiers.NewDataSet(raw.ClassNames, raw.AttributeNames, raw.Records); err != nil {
+			return nil, fmt.Errorf("Invalid data: %w", err)
+		}
+	case "text/csv":
+		bodyBytes, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return nil, errors.New("Unable to read body")
+		}
+		if ds, err = classifiers.FromCSV(bodyBytes); err != nil {
+			return nil, fmt.Errorf("Invalid data: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported content type %s", contentType)
+	}
+
+	if ds == nil {
+		return nil, errors.New("Invalid data: no dataset found")
+	}
+
+	return ds, nil
+}
+
 func TestModelHandler(rm *model.RunningModels) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		var (
@@ -135,16 +195,50 @@ func TestModelHandler(rm *model.RunningModels) echo.HandlerFunc {
 		if knnc, ok := c.Get(ContextKeyModel).(classifiers.Classifier); !ok {
 			return c.JSON(http.StatusNotFound, &model.ModelsError{Message: "Model not found"})
 		} else {
-			if results, err := knnc.Test(); err != nil {
-				return c.JSON(http.StatusBadRequest, err)
+			if results, err := knnc.TestContext(c.Request().Context()); err != nil {
+				return testError(c, err)
 			} else {
-				tra = results.Analyze()
+				training, _ := knnc.Data()
+				tra = results.AnalyzeClasses(training.ClassNames)
 			}
 		}
 		return c.JSON(http.StatusOK, tra)
 	}
 }
 
+// CurvesHandler returns an echo.HandlerFunc which tests the specified model and returns the
+// one-vs-rest ROC and precision-recall curves of each class
+func CurvesHandler(rm *model.RunningModels) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var (
+			curves classifiers.Curves
+		)
+
+		if cl, ok := c.Get(ContextKeyModel).(classifiers.Classifier); !ok {
+			return c.JSON(http.StatusNotFound, &model.ModelsError{Message: "Model not found"})
+		} else {
+			if results, err := cl.TestContext(c.Request().Context()); err != nil {
+				return testError(c, err)
+			} else {
+				training, _ := cl.Data()
+				curves = results.Curves(training.ClassNames)
+			}
+		}
+
+		return c.JSON(http.StatusOK, curves)
+	}
+}
+
+// testError renders the error returned when testing a model.  Tests are abandoned when the
+// request's context is cancelled (e.g. the client disconnects).
+func testError(c echo.Context, err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return c.JSON(http.StatusServiceUnavailable, &model.ModelsError{Message: fmt.Sprintf("Test abandoned: %s", err)})
+	}
+
+	return c.JSON(http.StatusBadRequest, err)
+}
+
 func TestResultsDetailsHandler(rm *model.RunningModels) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		var (
@@ -152,11 +246,24 @@ func TestResultsDetailsHandler(rm *model.RunningModels) echo.HandlerFunc {
 			err     error
 		)
 
-		if knnc, ok := c.Get(ContextKeyModel).(classifiers.Classifier); !ok {
+		explain := false
+		if param := c.QueryParam(QueryParamExplain); param != "" {
+			if explain, err = strconv.ParseBool(param); err != nil {
+				return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: fmt.Sprintf("Invalid value for %s: %s", QueryParamExplain, param)})
+			}
+		}
+
+		if cl, ok := c.Get(ContextKeyModel).(classifiers.Classifier); !ok {
 			return c.JSON(http.StatusNotFound, &model.ModelsError{Message: "Model not found"})
+		} else if explain {
+			if knnc, ok := cl.(*classifiers.KNearestNeighborClassifier); !ok {
+				return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: fmt.Sprintf("%s models cannot explain their results", cl.Type())})
+			} else if results, err = knnc.TestExplained(c.Request().Context()); err != nil {
+				return testError(c, err)
+			}
 		} else {
-			if results, err = knnc.Test(); err != nil {
-				return c.JSON(http.StatusBadRequest, err)
+			if results, err = cl.TestContext(c.Request().Context()); err != nil {
+				return testError(c, err)
 			}
 		}
 
@@ -164,3 +271,177 @@ func TestResultsDetailsHandler(rm *model.RunningModels) echo.HandlerFunc {
 	}
 
 }
+
+// PredictHandler returns an echo.HandlerFunc which classifies the unlabeled records in the request body
+func PredictHandler(rm *model.RunningModels) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var (
+			predictions []classifiers.Prediction
+			err         error
+		)
+
+		if knnc, ok := c.Get(ContextKeyModel).(classifiers.Classifier); !ok {
+			return c.JSON(http.StatusNotFound, &model.ModelsError{Message: "Model not found"})
+		} else {
+			pr := new(model.PredictionRequest)
+			if err = c.Bind(pr); err != nil {
+				return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: "Cannot parse request body"})
+			}
+
+			if predictions, err = knnc.PredictBatch(pr.Values); err != nil {
+				return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: fmt.Sprintf("Unable to make predictions: %s", err.Error())})
+			}
+		}
+
+		return c.JSON(http.StatusOK, predictions)
+	}
+}
+
+// TreeHandler returns an echo.HandlerFunc which renders the learned tree of a decision tree model
+func TreeHandler(rm *model.RunningModels) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var (
+			tree *classifiers.DecisionTreeNode
+		)
+
+		if cl, ok := c.Get(ContextKeyModel).(classifiers.Classifier); !ok {
+			return c.JSON(http.StatusNotFound, &model.ModelsError{Message: "Model not found"})
+		} else {
+			if dtc, ok := cl.(*classifiers.DecisionTreeClassifier); !ok {
+				return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: fmt.Sprintf("%s models do not have a tree", cl.Type())})
+			} else {
+				if tree = dtc.Tree(); tree == nil {
+					return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: "Model has not been trained"})
+				}
+			}
+		}
+
+		return c.JSON(http.StatusOK, tree)
+	}
+}
+
+// ForestRenderer reports what a random forest learned about its training data
+type ForestRenderer struct {
+	OutOfBagAccuracy     float64            `json:"out_of_bag_accuracy"`
+	AttributeImportances map[string]float64 `json:"attribute_importances"`
+}
+
+func ForestHandler(rm *model.RunningModels) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var (
+			forest ForestRenderer
+		)
+
+		if cl, ok := c.Get(ContextKeyModel).(classifiers.Classifier); !ok {
+			return c.JSON(http.StatusNotFound, &model.ModelsError{Message: "Model not found"})
+		} else {
+			if rfc, ok := cl.(*classifiers.RandomForestClassifier); !ok {
+				return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: fmt.Sprintf("%s models are not forests", cl.Type())})
+			} else {
+				if forest.AttributeImportances = rfc.AttributeImportances(); forest.AttributeImportances == nil {
+					return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: "Model has not been trained"})
+				}
+				forest.OutOfBagAccuracy = rfc.OutOfBagAccuracy()
+			}
+		}
+
+		return c.JSON(http.StatusOK, forest)
+	}
+}
+
+// CoefficientsRenderer reports the learned coefficients of a logistic regression, in terms of
+// the unstandardized attribute values, and the loss at the end of each training epoch
+type CoefficientsRenderer struct {
+	Weights     map[string]map[string]float64 `json:"weights"`
+	Intercepts  map[string]float64            `json:"intercepts"`
+	LossHistory []float64                     `json:"loss_history"`
+}
+
+func CoefficientsHandler(rm *model.RunningModels) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var (
+			coefficients CoefficientsRenderer
+		)
+
+		if cl, ok := c.Get(ContextKeyModel).(classifiers.Classifier); !ok {
+			return c.JSON(http.StatusNotFound, &model.ModelsError{Message: "Model not found"})
+		} else {
+			if lrc, ok := cl.(*classifiers.LogisticRegressionClassifier); !ok {
+				return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: fmt.Sprintf("%s models do not have coefficients", cl.Type())})
+			} else {
+				if coefficients.Weights = lrc.Weights(); coefficients.Weights == nil {
+					return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: "Model has not been trained"})
+				}
+				coefficients.Intercepts = lrc.Intercepts()
+				coefficients.LossHistory = lrc.LossHistory()
+			}
+		}
+
+		return c.JSON(http.StatusOK, coefficients)
+	}
+}
+
+// IndexRecallHandler returns an echo.HandlerFunc which compares the neighbors found by a
+// KNN model's index with an exact search over the model's test data
+func IndexRecallHandler(rm *model.RunningModels) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var (
+			report classifiers.IndexRecallReport
+			err    error
+		)
+
+		if cl, ok := c.Get(ContextKeyModel).(classifiers.Classifier); !ok {
+			return c.JSON(http.StatusNotFound, &model.ModelsError{Message: "Model not found"})
+		} else {
+			if knnc, ok := cl.(*classifiers.KNearestNeighborClassifier); !ok {
+				return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: fmt.Sprintf("%s models do not have a neighbor index", cl.Type())})
+			} else {
+				if report, err = knnc.IndexRecall(); err != nil {
+					return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: err.Error()})
+				}
+			}
+		}
+
+		return c.JSON(http.StatusOK, report)
+	}
+}
+
+// CrossValidateHandler cross-validates a new model configured like the running one on the
+// data the running model was given (all of it, not only its training data).  The running
+// model is not changed.
+func CrossValidateHandler(rm *model.RunningModels) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var (
+			report        *crossvalidation.Report
+			newClassifier crossvalidation.NewClassifier
+			err           error
+		)
+
+		if cl, ok := c.Get(ContextKeyModel).(classifiers.Classifier); !ok {
+			return c.JSON(http.StatusNotFound, &model.ModelsError{Message: "Model not found"})
+		} else {
+			cfg := new(crossvalidation.Config)
+			if err = c.Bind(cfg); err != nil {
+				return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: "Cannot parse request body"})
+			}
+
+			ds := cl.Raw()
+			if ds == nil {
+				return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: "Model has not been trained"})
+			}
+
+			if newClassifier, err = crossvalidation.Like(cl); err != nil {
+				return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: err.Error()})
+			}
+
+			if report, err = crossvalidation.Run(c.Request().Context(), ds, newClassifier, *cfg); err != nil {
+				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+					return testError(c, err)
+				}
+				return c.JSON(http.StatusBadRequest, &model.ModelsError{Message: fmt.Sprintf("Unable to cross-validate model: %s", err.Error())})
+			}
+		}
+
+		return c.JSON(http.StatusOK, report)
+	}
+}
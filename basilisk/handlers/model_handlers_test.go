@@ -2,6 +2,7 @@ package handlers_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -15,6 +16,8 @@ import (
 	"github.com/ScarletTanager/basilisk/basilisk/handlers"
 	"github.com/ScarletTanager/basilisk/basilisk/model"
 	"github.com/ScarletTanager/basilisk/classifiers"
+	"github.com/ScarletTanager/basilisk/crossvalidation"
+	"github.com/ScarletTanager/wyvern"
 )
 
 var _ = Describe("Model", func() {
@@ -49,6 +52,25 @@ var _ = Describe("Model", func() {
 		c = echo.New().NewContext(request, recorder)
 	})
 
+	Describe("ListDistanceMethodsHandler", func() {
+		BeforeEach(func() {
+			target = "/models/distance_methods"
+			method = http.MethodGet
+			bodyBytes = nil
+		})
+
+		It("Lists the available distance methods", func() {
+			handlers.ListDistanceMethodsHandler(c)
+			resp := recorder.Result()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var methods []classifiers.DistanceInfo
+			body, _ := io.ReadAll(resp.Body)
+			Expect(json.Unmarshal(body, &methods)).NotTo(HaveOccurred())
+			Expect(methods).To(ContainElement(HaveField("Name", classifiers.DistanceMethod_Cosine)))
+		})
+	})
+
 	Describe("CreateModelHandler", func() {
 		BeforeEach(func() {
 			method = http.MethodPost
@@ -126,6 +148,146 @@ var _ = Describe("Model", func() {
 			})
 		})
 
+		When("The request selects a naive Bayes model", func() {
+			BeforeEach(func() {
+				bodyBytes = []byte(`{
+					"type": "naive_bayes"
+				}`)
+			})
+
+			It("Creates a naive Bayes classifier", func() {
+				handlers.CreateModelHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusOK))
+				Expect(rm.Classifiers).To(HaveLen(1))
+				Expect(rm.Classifiers[0].Type()).To(Equal(classifiers.ClassifierType_NaiveBayes))
+			})
+		})
+
+		When("The request selects a random forest", func() {
+			BeforeEach(func() {
+				bodyBytes = []byte(`{
+					"type": "random_forest",
+					"trees": 10,
+					"max_depth": 4
+				}`)
+			})
+
+			It("Creates a random forest classifier", func() {
+				handlers.CreateModelHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusOK))
+				rfc, ok := rm.Classifiers[0].(*classifiers.RandomForestClassifier)
+				Expect(ok).To(BeTrue())
+				Expect(rfc.Configuration.Trees).To(Equal(10))
+				Expect(rfc.Configuration.MaxDepth).To(Equal(4))
+			})
+		})
+
+		When("The request selects a minkowski distance", func() {
+			BeforeEach(func() {
+				bodyBytes = []byte(`{
+					"k": 3,
+					"distance_method": "minkowski",
+					"minkowski_p": 3
+				}`)
+			})
+
+			It("Creates a KNN classifier using that distance", func() {
+				handlers.CreateModelHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusOK))
+				knnc, ok := rm.Classifiers[0].(*classifiers.KNearestNeighborClassifier)
+				Expect(ok).To(BeTrue())
+				Expect(knnc.Configuration.DistanceMethod).To(Equal(classifiers.DistanceMethod_Minkowski))
+				Expect(knnc.Configuration.MinkowskiP).To(Equal(3.0))
+			})
+		})
+
+		When("The request weights the attributes", func() {
+			BeforeEach(func() {
+				bodyBytes = []byte(`{
+					"k": 3,
+					"attribute_weights": {"length": 0.1, "bill": 2}
+				}`)
+			})
+
+			It("Creates a KNN classifier using the weights", func() {
+				handlers.CreateModelHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusOK))
+				knnc, ok := rm.Classifiers[0].(*classifiers.KNearestNeighborClassifier)
+				Expect(ok).To(BeTrue())
+				Expect(knnc.Configuration.AttributeWeights).To(Equal(map[string]float64{"length": .1, "bill": 2}))
+			})
+		})
+
+		When("The request selects K automatically", func() {
+			BeforeEach(func() {
+				bodyBytes = []byte(`{
+					"auto_k": true,
+					"auto_k_max": 15,
+					"auto_k_metric": "balanced_accuracy"
+				}`)
+			})
+
+			It("Creates a KNN classifier which selects K", func() {
+				handlers.CreateModelHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusOK))
+				knnc, ok := rm.Classifiers[0].(*classifiers.KNearestNeighborClassifier)
+				Expect(ok).To(BeTrue())
+				Expect(knnc.Configuration.AutoK).NotTo(BeNil())
+				Expect(knnc.Configuration.AutoK.MaxK).To(Equal(15))
+				Expect(knnc.Configuration.AutoK.Metric).To(Equal(classifiers.AutoKMetric_BalancedAccuracy))
+			})
+		})
+
+		When("The request reduces the training data", func() {
+			BeforeEach(func() {
+				bodyBytes = []byte(`{
+					"k": 3,
+					"reduction": "edited",
+					"reduction_edit_k": 5
+				}`)
+			})
+
+			It("Creates a KNN classifier which reduces its training data", func() {
+				handlers.CreateModelHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusOK))
+				knnc, ok := rm.Classifiers[0].(*classifiers.KNearestNeighborClassifier)
+				Expect(ok).To(BeTrue())
+				Expect(knnc.Configuration.Reduction).To(Equal(&classifiers.ReductionConfig{
+					Method: classifiers.Reduction_Edited,
+					EditK:  5,
+				}))
+			})
+		})
+
+		When("The request selects an unknown distance method", func() {
+			BeforeEach(func() {
+				bodyBytes = []byte(`{
+					"k": 3,
+					"distance_method": "hamming"
+				}`)
+			})
+
+			It("Returns an HTTP 400", func() {
+				handlers.CreateModelHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
+				Expect(rm.Classifiers).To(BeEmpty())
+			})
+		})
+
+		When("The request selects an unknown model type", func() {
+			BeforeEach(func() {
+				bodyBytes = []byte(`{
+					"type": "oracle"
+				}`)
+			})
+
+			It("Returns an HTTP 400", func() {
+				handlers.CreateModelHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
+				Expect(rm.Classifiers).To(BeEmpty())
+			})
+		})
+
 		When("The request body is invalid", func() {
 			BeforeEach(func() {
 				bodyBytes = []byte(`{
@@ -187,7 +349,7 @@ var _ = Describe("Model", func() {
 						It("Retrains the model", func() {
 							orig := knnc.TrainingData.Records
 
-							request = httptest.NewRequest(method, target, body)
+							request = httptest.NewRequest(method, target, bytes.NewReader(bodyBytes))
 							request.Header.Add("Content-type", "application/json")
 							newCtx := echo.New().NewContext(request, &httptest.ResponseRecorder{})
 							newCtx.Set(handlers.ContextKeyModel, knnc)
@@ -243,6 +405,23 @@ var _ = Describe("Model", func() {
 					Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
 				})
 			})
+
+			When("The body has too few records to train the model", func() {
+				BeforeEach(func() {
+					bodyBytes = []byte(`{
+						"classes": ["a", "b"],
+						"attributes": ["x"],
+						"data": [{"class": 0, "values": [1]}]
+					}`)
+				})
+
+				It("Returns a 400 with the training error and leaves the model untrained", func() {
+					handlers.TrainModelHandler(rm)(c)
+					Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
+					Expect(recorder.Body.String()).To(ContainSubstring("no training records"))
+					Expect(knnc.TrainingData).To(BeNil())
+				})
+			})
 		})
 
 		When("The model has not been set in the context", func() {
@@ -253,4 +432,678 @@ var _ = Describe("Model", func() {
 			})
 		})
 	})
+
+	Describe("CurvesHandler", func() {
+		BeforeEach(func() {
+			target = "/models/0/results/curves"
+			method = http.MethodGet
+			bodyBytes = nil
+		})
+
+		When("The model has been trained", func() {
+			JustBeforeEach(func() {
+				knnc, _ = classifiers.NewKnn(5, "")
+				Expect(knnc.TrainFromCSVFile("../../datasets/iris.csv", nil)).NotTo(HaveOccurred())
+				c.Set(handlers.ContextKeyModel, knnc)
+			})
+
+			It("Returns the curves of each class", func() {
+				handlers.CurvesHandler(rm)(c)
+				resp := recorder.Result()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				var curves classifiers.Curves
+				body, _ := io.ReadAll(resp.Body)
+				Expect(json.Unmarshal(body, &curves)).NotTo(HaveOccurred())
+				Expect(curves.Classes).To(HaveLen(len(knnc.TrainingData.ClassNames)))
+				for i, class := range curves.Classes {
+					Expect(class.Class).To(Equal(knnc.TrainingData.ClassNames[i]))
+				}
+			})
+		})
+
+		When("The model has not been trained", func() {
+			JustBeforeEach(func() {
+				knnc, _ = classifiers.NewKnn(5, "")
+				c.Set(handlers.ContextKeyModel, knnc)
+			})
+
+			It("Returns a 400", func() {
+				handlers.CurvesHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Describe("CrossValidateHandler", func() {
+		BeforeEach(func() {
+			target = "/models/0/crossvalidate"
+			method = http.MethodPost
+			bodyBytes = []byte(`{"method": "stratified", "folds": 4}`)
+		})
+
+		When("The model has been trained", func() {
+			JustBeforeEach(func() {
+				knnc, _ = classifiers.NewKnn(5, "")
+				Expect(knnc.TrainFromCSVFile("../../datasets/iris.csv", nil)).NotTo(HaveOccurred())
+				c.Set(handlers.ContextKeyModel, knnc)
+			})
+
+			It("Cross-validates the model on all of its data", func() {
+				handlers.CrossValidateHandler(rm)(c)
+				resp := recorder.Result()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				var report crossvalidation.Report
+				body, _ := io.ReadAll(resp.Body)
+				Expect(json.Unmarshal(body, &report)).NotTo(HaveOccurred())
+				Expect(report.Method).To(Equal(crossvalidation.Method_Stratified))
+				Expect(report.Folds).To(HaveLen(4))
+				Expect(report.Pooled.ResultCount).To(Equal(len(knnc.RawData.Records)))
+				Expect(report.Metrics).To(HaveKey(crossvalidation.Metric_Accuracy))
+			})
+
+			It("Does not change the model", func() {
+				training, testing := knnc.TrainingData, knnc.TestingData
+				handlers.CrossValidateHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusOK))
+				Expect(knnc.TrainingData).To(BeIdenticalTo(training))
+				Expect(knnc.TestingData).To(BeIdenticalTo(testing))
+			})
+
+			When("The model's training data has been reduced", func() {
+				JustBeforeEach(func() {
+					knnc, _ = classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+						K:         5,
+						Reduction: &classifiers.ReductionConfig{Method: classifiers.Reduction_Condensed},
+					})
+					Expect(knnc.TrainFromCSVFile("../../datasets/iris.csv", nil)).NotTo(HaveOccurred())
+					c.Set(handlers.ContextKeyModel, knnc)
+				})
+
+				It("Cross-validates on the data the model was given", func() {
+					handlers.CrossValidateHandler(rm)(c)
+					resp := recorder.Result()
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+					var report crossvalidation.Report
+					body, _ := io.ReadAll(resp.Body)
+					Expect(json.Unmarshal(body, &report)).NotTo(HaveOccurred())
+					Expect(len(knnc.TrainingData.Records) + len(knnc.TestingData.Records)).To(BeNumerically("<", len(knnc.RawData.Records)))
+					Expect(report.Pooled.ResultCount).To(Equal(len(knnc.RawData.Records)))
+				})
+			})
+
+			When("The body is empty", func() {
+				BeforeEach(func() {
+					bodyBytes = nil
+				})
+
+				It("Uses the default folds", func() {
+					handlers.CrossValidateHandler(rm)(c)
+					resp := recorder.Result()
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+					var report crossvalidation.Report
+					body, _ := io.ReadAll(resp.Body)
+					Expect(json.Unmarshal(body, &report)).NotTo(HaveOccurred())
+					Expect(report.Method).To(Equal(crossvalidation.Method_KFold))
+					Expect(report.Folds).To(HaveLen(crossvalidation.DEFAULT_FOLDS))
+				})
+			})
+
+			When("The method is unknown", func() {
+				BeforeEach(func() {
+					bodyBytes = []byte(`{"method": "bootstrap"}`)
+				})
+
+				It("Returns a 400", func() {
+					handlers.CrossValidateHandler(rm)(c)
+					Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
+				})
+			})
+
+			When("The request is cancelled", func() {
+				JustBeforeEach(func() {
+					ctx, cancel := context.WithCancel(context.Background())
+					cancel()
+					c.SetRequest(request.WithContext(ctx))
+				})
+
+				It("Abandons the cross-validation and returns a 503", func() {
+					handlers.CrossValidateHandler(rm)(c)
+					Expect(recorder.Result().StatusCode).To(Equal(http.StatusServiceUnavailable))
+				})
+			})
+		})
+
+		When("The model has not been trained", func() {
+			JustBeforeEach(func() {
+				knnc, _ = classifiers.NewKnn(5, "")
+				c.Set(handlers.ContextKeyModel, knnc)
+			})
+
+			It("Returns a 400", func() {
+				handlers.CrossValidateHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Describe("AddDataHandler", func() {
+		var ds *classifiers.DataSet
+
+		BeforeEach(func() {
+			target = "/models/0/data"
+			method = http.MethodPost
+			ds = &classifiers.DataSet{
+				ClassNames:     []string{"Iris-virginica"},
+				AttributeNames: []string{"sepal-length", "sepal-width", "petal-length", "petal-width"},
+				Records: []classifiers.Record{
+					{Class: 0, AttributeValues: wyvern.Vector[float64]{20, 20, 20, 20}},
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			// The body depends on the dataset, which the specs may change
+			bodyBytes, _ = json.Marshal(ds)
+			request = httptest.NewRequest(method, target, bytes.NewReader(bodyBytes))
+			request.Header.Add("Content-type", "application/json")
+			c = echo.New().NewContext(request, recorder)
+		})
+
+		When("The model is a trained KNN model", func() {
+			JustBeforeEach(func() {
+				knnc, _ = classifiers.NewKnn(1, "")
+				Expect(knnc.TrainFromCSVFile("../../datasets/iris.csv", nil)).NotTo(HaveOccurred())
+				c.Set(handlers.ContextKeyModel, knnc)
+			})
+
+			It("Adds the records to the training data, matching the classes by name", func() {
+				count := len(knnc.TrainingData.Records)
+				handlers.AddDataHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusOK))
+				Expect(knnc.TrainingData.Records).To(HaveLen(count + 1))
+				Expect(knnc.Predict(wyvern.Vector[float64]{19, 19, 19, 19})).To(HaveField("ClassName", "Iris-virginica"))
+			})
+
+			When("The data has different attributes", func() {
+				BeforeEach(func() {
+					ds.AttributeNames = []string{"a", "b", "c", "d"}
+				})
+
+				It("Returns a 400", func() {
+					handlers.AddDataHandler(rm)(c)
+					Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
+				})
+			})
+
+			When("The data has a class which the model does not know", func() {
+				BeforeEach(func() {
+					ds.ClassNames = []string{"Iris-pseudacorus"}
+				})
+
+				It("Returns a 400", func() {
+					handlers.AddDataHandler(rm)(c)
+					Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
+				})
+			})
+		})
+
+		When("The model has not been trained", func() {
+			JustBeforeEach(func() {
+				knnc, _ = classifiers.NewKnn(1, "")
+				c.Set(handlers.ContextKeyModel, knnc)
+			})
+
+			It("Returns a 400", func() {
+				handlers.AddDataHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("The model is not a KNN model", func() {
+			JustBeforeEach(func() {
+				dtc, _ := classifiers.NewDecisionTree(classifiers.DecisionTreeClassifierConfig{})
+				Expect(dtc.TrainFromCSVFile("../../datasets/iris.csv", nil)).NotTo(HaveOccurred())
+				c.Set(handlers.ContextKeyModel, dtc)
+			})
+
+			It("Returns a 400", func() {
+				handlers.AddDataHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Describe("TestModelHandler", func() {
+		BeforeEach(func() {
+			target = "/models/0/results"
+			method = http.MethodGet
+			bodyBytes = nil
+		})
+
+		JustBeforeEach(func() {
+			knnc, _ = classifiers.NewKnn(3, "")
+			Expect(knnc.TrainFromCSVFile("../../datasets/iris.csv", nil)).NotTo(HaveOccurred())
+			c.Set(handlers.ContextKeyModel, knnc)
+		})
+
+		It("Returns the analysis of the test results", func() {
+			handlers.TestModelHandler(rm)(c)
+			resp := recorder.Result()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var analysis classifiers.TestResultsAnalysis
+			body, _ := io.ReadAll(resp.Body)
+			Expect(json.Unmarshal(body, &analysis)).NotTo(HaveOccurred())
+			Expect(analysis.ResultCount).To(Equal(len(knnc.TestingData.Records)))
+		})
+
+		It("Includes the confusion matrix and the metrics of each class", func() {
+			handlers.TestModelHandler(rm)(c)
+			resp := recorder.Result()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var analysis classifiers.TestResultsAnalysis
+			body, _ := io.ReadAll(resp.Body)
+			Expect(json.Unmarshal(body, &analysis)).NotTo(HaveOccurred())
+			Expect(analysis.ConfusionMatrix).NotTo(BeNil())
+			Expect(analysis.ConfusionMatrix.Labels).To(Equal(knnc.TrainingData.ClassNames))
+			Expect(analysis.Classes).To(HaveLen(len(knnc.TrainingData.ClassNames)))
+			Expect(analysis.MacroAverage).NotTo(BeNil())
+			Expect(analysis.MicroAverage).NotTo(BeNil())
+			Expect(analysis.WeightedAverage).NotTo(BeNil())
+		})
+
+		It("Includes the calibration of the probabilities", func() {
+			handlers.TestModelHandler(rm)(c)
+			resp := recorder.Result()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var analysis classifiers.TestResultsAnalysis
+			body, _ := io.ReadAll(resp.Body)
+			Expect(json.Unmarshal(body, &analysis)).NotTo(HaveOccurred())
+			Expect(analysis.Calibration).NotTo(BeNil())
+			Expect(analysis.Calibration.ReliabilityBins).To(HaveLen(classifiers.DEFAULT_CALIBRATION_BINS))
+		})
+
+		It("Includes the agreement metrics", func() {
+			handlers.TestModelHandler(rm)(c)
+			resp := recorder.Result()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var analysis classifiers.TestResultsAnalysis
+			body, _ := io.ReadAll(resp.Body)
+			Expect(json.Unmarshal(body, &analysis)).NotTo(HaveOccurred())
+			Expect(analysis.Agreement).NotTo(BeNil())
+			Expect(analysis.Agreement.CohensKappa).To(BeNumerically(">", .5))
+			Expect(analysis.Agreement.TopKAccuracy).To(HaveLen(len(knnc.TrainingData.ClassNames)))
+		})
+
+		When("The request is cancelled", func() {
+			JustBeforeEach(func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				c.SetRequest(request.WithContext(ctx))
+			})
+
+			It("Abandons the test and returns a 503", func() {
+				handlers.TestModelHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusServiceUnavailable))
+			})
+		})
+	})
+
+	Describe("TestResultsDetailsHandler", func() {
+		BeforeEach(func() {
+			target = "/models/0/results/details"
+			method = http.MethodGet
+			bodyBytes = nil
+		})
+
+		JustBeforeEach(func() {
+			knnc, _ = classifiers.NewKnn(3, "")
+			Expect(knnc.TrainFromCSVFile("../../datasets/iris.csv", nil)).NotTo(HaveOccurred())
+			c.Set(handlers.ContextKeyModel, knnc)
+		})
+
+		It("Returns the test results without neighbors", func() {
+			handlers.TestResultsDetailsHandler(rm)(c)
+			resp := recorder.Result()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var results classifiers.TestResults
+			body, _ := io.ReadAll(resp.Body)
+			Expect(json.Unmarshal(body, &results)).NotTo(HaveOccurred())
+			Expect(results).To(HaveLen(len(knnc.TestingData.Records)))
+			for _, result := range results {
+				Expect(result.Neighbors).To(BeNil())
+			}
+		})
+
+		When("Explanations are requested", func() {
+			BeforeEach(func() {
+				target = "/models/0/results/details?explain=true"
+			})
+
+			It("Returns the neighbors which voted on each prediction", func() {
+				handlers.TestResultsDetailsHandler(rm)(c)
+				resp := recorder.Result()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				var results classifiers.TestResults
+				body, _ := io.ReadAll(resp.Body)
+				Expect(json.Unmarshal(body, &results)).NotTo(HaveOccurred())
+				Expect(results).To(HaveLen(len(knnc.TestingData.Records)))
+				for _, result := range results {
+					Expect(result.Neighbors).To(HaveLen(3))
+					for _, neighbor := range result.Neighbors {
+						Expect(neighbor.AttributeValues).To(Equal(knnc.TrainingData.Records[neighbor.Index].AttributeValues))
+					}
+				}
+			})
+
+			When("The model is not a KNN model", func() {
+				JustBeforeEach(func() {
+					dtc, _ := classifiers.NewDecisionTree(classifiers.DecisionTreeClassifierConfig{})
+					Expect(dtc.TrainFromCSVFile("../../datasets/iris.csv", nil)).NotTo(HaveOccurred())
+					c.Set(handlers.ContextKeyModel, dtc)
+				})
+
+				It("Returns a 400", func() {
+					handlers.TestResultsDetailsHandler(rm)(c)
+					Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
+				})
+			})
+		})
+
+		When("The explain parameter is not a boolean", func() {
+			BeforeEach(func() {
+				target = "/models/0/results/details?explain=please"
+			})
+
+			It("Returns a 400", func() {
+				handlers.TestResultsDetailsHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Describe("PredictHandler", func() {
+		BeforeEach(func() {
+			target = "/models/0/predictions"
+			method = http.MethodPost
+			knnc, _ = classifiers.NewKnn(1, "")
+			Expect(knnc.TrainFromCSVFile("../../fixtures/students.csv", &classifiers.DataSplitConfig{Method: classifiers.SplitSequential})).NotTo(HaveOccurred())
+			bodyBytes = []byte(`{"values": [[8.5], [17.0]]}`)
+		})
+
+		When("The model has been set in the context", func() {
+			JustBeforeEach(func() {
+				c.Set(handlers.ContextKeyModel, knnc)
+			})
+
+			It("Returns the predictions", func() {
+				handlers.PredictHandler(rm)(c)
+				resp := recorder.Result()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				var predictions []classifiers.Prediction
+				body, _ := io.ReadAll(resp.Body)
+				Expect(json.Unmarshal(body, &predictions)).NotTo(HaveOccurred())
+				Expect(predictions).To(HaveLen(2))
+				Expect(predictions[0].ClassName).To(Equal("Elementary"))
+				Expect(predictions[1].ClassName).To(Equal("High"))
+			})
+
+			When("A record has the wrong number of attributes", func() {
+				BeforeEach(func() {
+					bodyBytes = []byte(`{"values": [[8.5, 1.0]]}`)
+				})
+
+				It("Returns a 400", func() {
+					handlers.PredictHandler(rm)(c)
+					Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
+				})
+			})
+		})
+
+		When("The model has not been set in the context", func() {
+			It("Returns a 404", func() {
+				handlers.PredictHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusNotFound))
+			})
+		})
+	})
+
+	Describe("TreeHandler", func() {
+		var (
+			dtc *classifiers.DecisionTreeClassifier
+		)
+
+		BeforeEach(func() {
+			target = "/models/0/tree"
+			method = http.MethodGet
+			bodyBytes = nil
+			dtc, _ = classifiers.NewDecisionTree(classifiers.DecisionTreeClassifierConfig{})
+		})
+
+		When("The model is a trained decision tree", func() {
+			BeforeEach(func() {
+				Expect(dtc.TrainFromCSVFile("../../datasets/iris.csv", nil)).NotTo(HaveOccurred())
+			})
+
+			JustBeforeEach(func() {
+				c.Set(handlers.ContextKeyModel, dtc)
+			})
+
+			It("Returns the tree", func() {
+				handlers.TreeHandler(rm)(c)
+				resp := recorder.Result()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				var tree map[string]interface{}
+				body, _ := io.ReadAll(resp.Body)
+				Expect(json.Unmarshal(body, &tree)).NotTo(HaveOccurred())
+				Expect(tree).To(HaveKey("split"))
+			})
+		})
+
+		When("The decision tree has not been trained", func() {
+			JustBeforeEach(func() {
+				c.Set(handlers.ContextKeyModel, dtc)
+			})
+
+			It("Returns a 400", func() {
+				handlers.TreeHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("The model is not a decision tree", func() {
+			JustBeforeEach(func() {
+				knnc, _ = classifiers.NewKnn(1, "")
+				c.Set(handlers.ContextKeyModel, knnc)
+			})
+
+			It("Returns a 400", func() {
+				handlers.TreeHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Describe("ForestHandler", func() {
+		var (
+			rfc *classifiers.RandomForestClassifier
+		)
+
+		BeforeEach(func() {
+			target = "/models/0/forest"
+			method = http.MethodGet
+			bodyBytes = nil
+			rfc, _ = classifiers.NewRandomForest(classifiers.RandomForestClassifierConfig{Trees: 10, Seed: 3})
+		})
+
+		When("The model is a trained random forest", func() {
+			BeforeEach(func() {
+				Expect(rfc.TrainFromCSVFile("../../datasets/iris.csv", nil)).NotTo(HaveOccurred())
+			})
+
+			JustBeforeEach(func() {
+				c.Set(handlers.ContextKeyModel, rfc)
+			})
+
+			It("Returns the out-of-bag accuracy and the attribute importances", func() {
+				handlers.ForestHandler(rm)(c)
+				resp := recorder.Result()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				var forest handlers.ForestRenderer
+				body, _ := io.ReadAll(resp.Body)
+				Expect(json.Unmarshal(body, &forest)).NotTo(HaveOccurred())
+				Expect(forest.OutOfBagAccuracy).To(Equal(rfc.OutOfBagAccuracy()))
+				Expect(forest.AttributeImportances).To(Equal(rfc.AttributeImportances()))
+			})
+		})
+
+		When("The random forest has not been trained", func() {
+			JustBeforeEach(func() {
+				c.Set(handlers.ContextKeyModel, rfc)
+			})
+
+			It("Returns a 400", func() {
+				handlers.ForestHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("The model is not a random forest", func() {
+			JustBeforeEach(func() {
+				knnc, _ = classifiers.NewKnn(1, "")
+				c.Set(handlers.ContextKeyModel, knnc)
+			})
+
+			It("Returns a 400", func() {
+				handlers.ForestHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Describe("CoefficientsHandler", func() {
+		var (
+			lrc *classifiers.LogisticRegressionClassifier
+		)
+
+		BeforeEach(func() {
+			target = "/models/0/coefficients"
+			method = http.MethodGet
+			bodyBytes = nil
+			lrc, _ = classifiers.NewLogisticRegression(classifiers.LogisticRegressionClassifierConfig{Seed: 7})
+		})
+
+		When("The model is a trained logistic regression", func() {
+			BeforeEach(func() {
+				Expect(lrc.TrainFromCSVFile("../../datasets/iris.csv", nil)).NotTo(HaveOccurred())
+			})
+
+			JustBeforeEach(func() {
+				c.Set(handlers.ContextKeyModel, lrc)
+			})
+
+			It("Returns the weights, intercepts and loss history", func() {
+				handlers.CoefficientsHandler(rm)(c)
+				resp := recorder.Result()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				var coefficients handlers.CoefficientsRenderer
+				body, _ := io.ReadAll(resp.Body)
+				Expect(json.Unmarshal(body, &coefficients)).NotTo(HaveOccurred())
+				Expect(coefficients.Weights).To(HaveLen(len(lrc.TrainingData.ClassNames)))
+				Expect(coefficients.Intercepts).To(HaveLen(len(lrc.TrainingData.ClassNames)))
+				Expect(coefficients.LossHistory).To(HaveLen(len(lrc.LossHistory())))
+			})
+		})
+
+		When("The logistic regression has not been trained", func() {
+			JustBeforeEach(func() {
+				c.Set(handlers.ContextKeyModel, lrc)
+			})
+
+			It("Returns a 400", func() {
+				handlers.CoefficientsHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("The model is not a logistic regression", func() {
+			JustBeforeEach(func() {
+				knnc, _ = classifiers.NewKnn(1, "")
+				c.Set(handlers.ContextKeyModel, knnc)
+			})
+
+			It("Returns a 400", func() {
+				handlers.CoefficientsHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Describe("IndexRecallHandler", func() {
+		BeforeEach(func() {
+			target = "/models/0/index/recall"
+			method = http.MethodGet
+			bodyBytes = nil
+		})
+
+		When("The model is a trained KNN model", func() {
+			JustBeforeEach(func() {
+				knnc, _ = classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+					K:     3,
+					Index: classifiers.IndexMethod_HNSW,
+				})
+				Expect(knnc.TrainFromCSVFile("../../datasets/iris.csv", nil)).NotTo(HaveOccurred())
+				c.Set(handlers.ContextKeyModel, knnc)
+			})
+
+			It("Returns the recall report", func() {
+				handlers.IndexRecallHandler(rm)(c)
+				resp := recorder.Result()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+				var report classifiers.IndexRecallReport
+				body, _ := io.ReadAll(resp.Body)
+				Expect(json.Unmarshal(body, &report)).NotTo(HaveOccurred())
+				Expect(report.Index).To(Equal(classifiers.IndexMethod_HNSW))
+				Expect(report.Recall).To(BeNumerically(">", .9))
+			})
+		})
+
+		When("The model has not been trained", func() {
+			JustBeforeEach(func() {
+				knnc, _ = classifiers.NewKnn(1, "")
+				c.Set(handlers.ContextKeyModel, knnc)
+			})
+
+			It("Returns a 400", func() {
+				handlers.IndexRecallHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		When("The model is not a KNN model", func() {
+			JustBeforeEach(func() {
+				dtc, _ := classifiers.NewDecisionTree(classifiers.DecisionTreeClassifierConfig{})
+				c.Set(handlers.ContextKeyModel, dtc)
+			})
+
+			It("Returns a 400", func() {
+				handlers.IndexRecallHandler(rm)(c)
+				Expect(recorder.Result().StatusCode).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
 })
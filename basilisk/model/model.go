@@ -2,13 +2,132 @@ package model
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/ScarletTanager/basilisk/classifiers"
+	"github.com/ScarletTanager/wyvern"
+)
+
+const (
+	ModelType_KNearestNeighbors = "knn"
+	ModelType_NaiveBayes        = "naive_bayes"
+	ModelType_DecisionTree      = "decision_tree"
+	ModelType_RandomForest      = "random_forest"
+	ModelType_Logistic          = "logistic_regression"
 )
 
 type ModelConfiguration struct {
-	K              int    `json:"k,omitempty"`
-	DistanceMethod string `json:"distance_method"`
+	// Type selects the kind of model - if empty, a KNearestNeighbors classifier is created
+	Type string `json:"type,omitempty"`
+	K    int    `json:"k,omitempty"`
+	// AutoK selects K for KNN models by cross-validation, ignoring K
+	AutoK          bool    `json:"auto_k,omitempty"`
+	AutoKMin       int     `json:"auto_k_min,omitempty"`
+	AutoKMax       int     `json:"auto_k_max,omitempty"`
+	AutoKFolds     int     `json:"auto_k_folds,omitempty"`
+	AutoKMetric    string  `json:"auto_k_metric,omitempty"`
+	DistanceMethod string  `json:"distance_method"`
+	MinkowskiP     float64 `json:"minkowski_p,omitempty"`
+	// AttributeWeights scales the attributes (keyed by name) in KNN distance computations
+	AttributeWeights  map[string]float64 `json:"attribute_weights,omitempty"`
+	Weighting         string             `json:"weighting,omitempty"`
+	Bandwidth         float64            `json:"bandwidth,omitempty"`
+	TieBreak          string             `json:"tie_break,omitempty"`
+	Index             string             `json:"index,omitempty"`
+	HNSWM             int                `json:"hnsw_m,omitempty"`
+	HNSWEfConstruct   int                `json:"hnsw_ef_construction,omitempty"`
+	HNSWEfSearch      int                `json:"hnsw_ef_search,omitempty"`
+	Concurrency       int                `json:"concurrency,omitempty"`
+	Reduction         string             `json:"reduction,omitempty"`
+	ReductionEditK    int                `json:"reduction_edit_k,omitempty"`
+	VarianceSmoothing float64            `json:"variance_smoothing,omitempty"`
+	Criterion         string             `json:"criterion,omitempty"`
+	MaxDepth          int                `json:"max_depth,omitempty"`
+	MinSamplesLeaf    int                `json:"min_samples_leaf,omitempty"`
+	Trees             int                `json:"trees,omitempty"`
+	MaxFeatures       int                `json:"max_features,omitempty"`
+	Seed              int64              `json:"seed,omitempty"`
+	LearningRate      float64            `json:"learning_rate,omitempty"`
+	Epochs            int                `json:"epochs,omitempty"`
+	BatchSize         int                `json:"batch_size,omitempty"`
+	L2                float64            `json:"l2,omitempty"`
+	Tolerance         float64            `json:"tolerance,omitempty"`
+}
+
+// NewClassifier creates an (untrained) classifier from the configuration
+func (mc *ModelConfiguration) NewClassifier() (classifiers.Classifier, error) {
+	switch mc.Type {
+	case "", ModelType_KNearestNeighbors:
+		return classifiers.NewKnnFromConfig(mc.knnConfig())
+	case ModelType_NaiveBayes:
+		return classifiers.NewNaiveBayes(mc.VarianceSmoothing)
+	case ModelType_DecisionTree:
+		return classifiers.NewDecisionTree(mc.decisionTreeConfig())
+	case ModelType_RandomForest:
+		return classifiers.NewRandomForest(classifiers.RandomForestClassifierConfig{
+			DecisionTreeClassifierConfig: mc.decisionTreeConfig(),
+			Trees:                        mc.Trees,
+			MaxFeatures:                  mc.MaxFeatures,
+			Seed:                         mc.Seed,
+		})
+	case ModelType_Logistic:
+		return classifiers.NewLogisticRegression(classifiers.LogisticRegressionClassifierConfig{
+			LearningRate: mc.LearningRate,
+			Epochs:       mc.Epochs,
+			BatchSize:    mc.BatchSize,
+			L2:           mc.L2,
+			Tolerance:    mc.Tolerance,
+			Seed:         mc.Seed,
+		})
+	}
+
+	return nil, fmt.Errorf("Unknown model type %s", mc.Type)
+}
+
+func (mc *ModelConfiguration) knnConfig() classifiers.KNearestNeighborClassifierConfig {
+	cfg := classifiers.KNearestNeighborClassifierConfig{
+		K:              mc.K,
+		DistanceMethod: mc.DistanceMethod,
+		MinkowskiP:     mc.MinkowskiP,
+		Weighting:      mc.Weighting,
+		Bandwidth:      mc.Bandwidth,
+		TieBreak:       mc.TieBreak,
+		Seed:           mc.Seed,
+		Index:          mc.Index,
+		HNSW: classifiers.HNSWConfig{
+			M:              mc.HNSWM,
+			EfConstruction: mc.HNSWEfConstruct,
+			EfSearch:       mc.HNSWEfSearch,
+		},
+		Concurrency:      mc.Concurrency,
+		AttributeWeights: mc.AttributeWeights,
+	}
+
+	if mc.AutoK {
+		cfg.AutoK = &classifiers.AutoKConfig{
+			MinK:   mc.AutoKMin,
+			MaxK:   mc.AutoKMax,
+			Folds:  mc.AutoKFolds,
+			Metric: mc.AutoKMetric,
+		}
+	}
+
+	if mc.Reduction != "" {
+		cfg.Reduction = &classifiers.ReductionConfig{
+			Method: mc.Reduction,
+			EditK:  mc.ReductionEditK,
+		}
+	}
+
+	return cfg
+}
+
+func (mc *ModelConfiguration) decisionTreeConfig() classifiers.DecisionTreeClassifierConfig {
+	return classifiers.DecisionTreeClassifierConfig{
+		Criterion:      mc.Criterion,
+		MaxDepth:       mc.MaxDepth,
+		MinSamplesLeaf: mc.MinSamplesLeaf,
+	}
 }
 
 type Model struct {
@@ -16,6 +135,11 @@ type Model struct {
 	ModelConfiguration
 }
 
+// PredictionRequest is the body of a request to classify one or more unlabeled records
+type PredictionRequest struct {
+	Values []wyvern.Vector[float64] `json:"values"`
+}
+
 type RunningModels struct {
 	Classifiers []classifiers.Classifier
 }
@@ -25,7 +149,7 @@ type ModelsError struct {
 	Error   error  `json:"error,omitempty"`
 }
 
-func (rm *RunningModels) Add(cl *classifiers.KNearestNeighborClassifier) (int, error) {
+func (rm *RunningModels) Add(cl classifiers.Classifier) (int, error) {
 	if cl == nil {
 		return -1, errors.New("Cannot add a nil classifier")
 	}
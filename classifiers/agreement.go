@@ -0,0 +1,133 @@
+package classifiers
+
+import (
+	"math"
+)
+
+const (
+	// DEFAULT_TOP_K is the largest K for which the analysis reports the top-K accuracy
+	DEFAULT_TOP_K = 5
+)
+
+// Agreement measures the agreement between the predicted and actual classes, allowing for the
+// agreement expected by chance - unlike the accuracy, these are not flattered by predicting
+// the most common class when the classes are imbalanced.  Results for which no prediction was
+// made count as disagreeing.
+type Agreement struct {
+	// CohensKappa is the share of the agreement beyond that expected by chance (given how
+	// often each class is predicted and occurs) which was achieved: 1 is perfect agreement,
+	// 0 no better than chance.  It is 0 if all of the agreement is expected by chance.
+	CohensKappa float64 `json:"cohens_kappa"`
+	// MatthewsCorrelation is the (multi-class) Matthews correlation coefficient between the
+	// predicted and actual classes, from -1 to 1.  It is 0 if either only ever takes one value.
+	MatthewsCorrelation float64 `json:"matthews_correlation"`
+	// BalancedAccuracy is the mean recall over the classes which occur in the results
+	BalancedAccuracy float64 `json:"balanced_accuracy"`
+	// TopKAccuracy[k-1] is the share of the results whose actual class is among the k most
+	// probable classes (with classes of equal probability ranked in class order), for k up
+	// to DEFAULT_TOP_K or the number of classes
+	TopKAccuracy []float64 `json:"top_k_accuracy"`
+}
+
+// Agreement computes the agreement metrics of the results.  classNames must list the names
+// of the classes of the results, as in DataSet.ClassNames.  Returns nil if there are no results.
+func (trs TestResults) Agreement(classNames []string) *Agreement {
+	if len(trs) == 0 {
+		return nil
+	}
+
+	agreement := &Agreement{
+		BalancedAccuracy: trs.BalancedAccuracy(len(classNames)),
+		TopKAccuracy:     make([]float64, min(DEFAULT_TOP_K, len(classNames))),
+	}
+
+	// actual[c] and predicted[c] count the results of each class and predicted to be of
+	// each class.  Results without a prediction are counted in the extra, last element of
+	// predicted.
+	actual := make([]float64, len(classNames))
+	predicted := make([]float64, len(classNames)+1)
+	var correct float64
+	for _, result := range trs {
+		actual[result.Class]++
+		if result.Predicted == NO_PREDICTION {
+			predicted[len(classNames)]++
+		} else {
+			predicted[result.Predicted]++
+		}
+
+		if result.Predicted == result.Class {
+			correct++
+		}
+
+		for k := classRank(result, classNames); k < len(agreement.TopKAccuracy); k++ {
+			agreement.TopKAccuracy[k]++
+		}
+	}
+
+	n := float64(len(trs))
+	var chance, actualSquares, predictedSquares float64
+	for c := range actual {
+		chance += actual[c] * predicted[c]
+		actualSquares += actual[c] * actual[c]
+	}
+	for _, count := range predicted {
+		predictedSquares += count * count
+	}
+
+	if expected := chance / (n * n); expected < 1 {
+		agreement.CohensKappa = (correct/n - expected) / (1 - expected)
+	}
+
+	if denominator := math.Sqrt((n*n - predictedSquares) * (n*n - actualSquares)); denominator > 0 {
+		agreement.MatthewsCorrelation = (correct*n - chance) / denominator
+	}
+
+	for k := range agreement.TopKAccuracy {
+		agreement.TopKAccuracy[k] /= n
+	}
+
+	return agreement
+}
+
+// classRank returns the position (from 0) of the actual class of the result when the classes
+// are ordered by descending probability, with classes of equal probability in class order
+func classRank(result TestResult, classNames []string) int {
+	p := result.Probabilities[classNames[result.Class]]
+	rank := 0
+	for c, className := range classNames {
+		if other := result.Probabilities[className]; other > p || (other == p && c < result.Class) {
+			rank++
+		}
+	}
+
+	return rank
+}
+
+// BalancedAccuracy returns the mean over the classes of the share of the results of the class
+// which were predicted correctly (the recall), leaving out classes with no results.  Returns 0
+// if there are no results.
+func (trs TestResults) BalancedAccuracy(classCount int) float64 {
+	correct := make([]int, classCount)
+	total := make([]int, classCount)
+	for _, r := range trs {
+		total[r.Class]++
+		if r.Predicted == r.Class {
+			correct[r.Class]++
+		}
+	}
+
+	var recall float64
+	var present int
+	for c := range total {
+		if total[c] > 0 {
+			recall += float64(correct[c]) / float64(total[c])
+			present++
+		}
+	}
+
+	if present == 0 {
+		return 0
+	}
+
+	return recall / float64(present)
+}
@@ -0,0 +1,123 @@
+package classifiers_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ScarletTanager/basilisk/classifiers"
+)
+
+var _ = Describe("Agreement", func() {
+	var (
+		classNames []string
+		results    classifiers.TestResults
+	)
+
+	result := func(actual, predicted int) classifiers.TestResult {
+		return classifiers.TestResult{
+			Record:    classifiers.Record{Class: actual},
+			Predicted: predicted,
+		}
+	}
+
+	BeforeEach(func() {
+		classNames = []string{"a", "b"}
+		results = classifiers.TestResults{
+			result(0, 0), result(0, 0), result(0, 1),
+			result(1, 1), result(1, 1), result(1, 0),
+		}
+	})
+
+	It("Computes Cohen's kappa", func() {
+		// 2/3 agreement, where 1/2 is expected by chance
+		Expect(results.Agreement(classNames).CohensKappa).To(BeNumerically("~", 1.0/3))
+	})
+
+	It("Computes the Matthews correlation coefficient", func() {
+		// (TP*TN - FP*FN) / sqrt((TP+FP)(TP+FN)(TN+FP)(TN+FN)) = (4-1)/9
+		Expect(results.Agreement(classNames).MatthewsCorrelation).To(BeNumerically("~", 1.0/3))
+	})
+
+	It("Computes the balanced accuracy", func() {
+		Expect(results.Agreement(classNames).BalancedAccuracy).To(BeNumerically("~", 2.0/3))
+	})
+
+	When("The model always predicts the most common class", func() {
+		BeforeEach(func() {
+			results = make(classifiers.TestResults, 0, 10)
+			for i := 0; i < 9; i++ {
+				results = append(results, result(0, 0))
+			}
+			results = append(results, result(1, 0))
+		})
+
+		It("Is not flattered by the accuracy", func() {
+			agreement := results.Agreement(classNames)
+			Expect(results.Analyze().Accuracy).To(Equal(.9))
+			Expect(agreement.CohensKappa).To(BeNumerically("~", 0))
+			Expect(agreement.MatthewsCorrelation).To(BeZero())
+			Expect(agreement.BalancedAccuracy).To(BeNumerically("~", .5))
+		})
+	})
+
+	When("The predictions are perfect", func() {
+		BeforeEach(func() {
+			results = classifiers.TestResults{result(0, 0), result(1, 1), result(1, 1)}
+		})
+
+		It("Reports perfect agreement", func() {
+			agreement := results.Agreement(classNames)
+			Expect(agreement.CohensKappa).To(BeNumerically("~", 1))
+			Expect(agreement.MatthewsCorrelation).To(BeNumerically("~", 1))
+			Expect(agreement.BalancedAccuracy).To(Equal(1.0))
+		})
+	})
+
+	When("No prediction is made for some results", func() {
+		BeforeEach(func() {
+			results = classifiers.TestResults{result(0, 0), result(1, 1), result(1, classifiers.NO_PREDICTION)}
+		})
+
+		It("Counts them as disagreeing", func() {
+			agreement := results.Agreement(classNames)
+			Expect(agreement.CohensKappa).To(BeNumerically("<", 1))
+			Expect(agreement.MatthewsCorrelation).To(BeNumerically("<", 1))
+			Expect(agreement.BalancedAccuracy).To(BeNumerically("~", .75))
+		})
+	})
+
+	Describe("TopKAccuracy", func() {
+		BeforeEach(func() {
+			classNames = []string{"a", "b", "c"}
+			results = classifiers.TestResults{
+				{Record: classifiers.Record{Class: 2}, Probabilities: map[string]float64{"a": .5, "b": .3, "c": .2}},
+				// Tied with a, which comes first
+				{Record: classifiers.Record{Class: 1}, Probabilities: map[string]float64{"a": .5, "b": .5}},
+				{Record: classifiers.Record{Class: 0}, Probabilities: map[string]float64{"a": 1}},
+			}
+		})
+
+		It("Reports the share of results whose class is among the K most probable", func() {
+			topK := results.Agreement(classNames).TopKAccuracy
+			Expect(topK).To(HaveLen(3))
+			Expect(topK[0]).To(BeNumerically("~", 1.0/3))
+			Expect(topK[1]).To(BeNumerically("~", 2.0/3))
+			Expect(topK[2]).To(Equal(1.0))
+		})
+
+		It("Stops at DEFAULT_TOP_K", func() {
+			classNames = []string{"a", "b", "c", "d", "e", "f", "g"}
+			Expect(results.Agreement(classNames).TopKAccuracy).To(HaveLen(classifiers.DEFAULT_TOP_K))
+		})
+	})
+
+	When("There are no results", func() {
+		It("Returns nil", func() {
+			Expect(classifiers.TestResults{}.Agreement(classNames)).To(BeNil())
+		})
+	})
+
+	It("Is included in the analysis of the classes", func() {
+		Expect(results.AnalyzeClasses(classNames).Agreement).To(Equal(results.Agreement(classNames)))
+	})
+})
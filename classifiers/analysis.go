@@ -0,0 +1,133 @@
+package classifiers
+
+// ConfusionMatrix counts the test results by actual and predicted class
+type ConfusionMatrix struct {
+	// Labels names the classes of both the rows and the columns
+	Labels []string `json:"labels"`
+	// Counts[a][p] is the number of records of class a which were predicted to be of class p
+	Counts [][]int `json:"counts"`
+	// Unpredicted[a] is the number of records of class a for which no prediction was made
+	Unpredicted []int `json:"unpredicted"`
+}
+
+// ClassMetrics measures the predictions of a single class.  Precision, recall and F1 are 0
+// when they would otherwise be undefined (e.g. the precision of a class which was never
+// predicted).
+type ClassMetrics struct {
+	Class string `json:"class"`
+	// Precision is the share of the records predicted to be of the class which are
+	Precision float64 `json:"precision"`
+	// Recall is the share of the records of the class which were predicted to be
+	Recall float64 `json:"recall"`
+	// F1 is the harmonic mean of the precision and recall
+	F1 float64 `json:"f1"`
+	// Support is the number of records of the class
+	Support int `json:"support"`
+}
+
+// AverageMetrics averages the ClassMetrics over the classes.  The macro average weights each
+// class equally, the weighted average weights each class by its support, and the micro
+// average is computed from the total counts over all classes.
+type AverageMetrics struct {
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+// AnalyzeClasses analyzes the results like Analyze, adding the confusion matrix, the
+// metrics of each class, the calibration of the probabilities and the agreement metrics.
+// classNames must list the names of the classes of the results, as in DataSet.ClassNames.
+func (trs TestResults) AnalyzeClasses(classNames []string) TestResultsAnalysis {
+	analysis := trs.Analyze()
+
+	matrix := &ConfusionMatrix{
+		Labels:      classNames,
+		Counts:      make([][]int, len(classNames)),
+		Unpredicted: make([]int, len(classNames)),
+	}
+	for i := range matrix.Counts {
+		matrix.Counts[i] = make([]int, len(classNames))
+	}
+
+	for _, result := range trs {
+		if result.Predicted == NO_PREDICTION {
+			matrix.Unpredicted[result.Class]++
+		} else {
+			matrix.Counts[result.Class][result.Predicted]++
+		}
+	}
+
+	var (
+		macro, weighted                     AverageMetrics
+		truePositives, predicted, supported int
+	)
+
+	analysis.Classes = make([]ClassMetrics, len(classNames))
+	for c, className := range classNames {
+		tp, predictedAs, support := matrix.Counts[c][c], 0, matrix.Unpredicted[c]
+		for other := range classNames {
+			predictedAs += matrix.Counts[other][c]
+			support += matrix.Counts[c][other]
+		}
+
+		metrics := ClassMetrics{
+			Class:     className,
+			Precision: ratio(tp, predictedAs),
+			Recall:    ratio(tp, support),
+			Support:   support,
+		}
+		metrics.F1 = harmonicMean(metrics.Precision, metrics.Recall)
+		analysis.Classes[c] = metrics
+
+		macro.Precision += metrics.Precision
+		macro.Recall += metrics.Recall
+		macro.F1 += metrics.F1
+		weighted.Precision += metrics.Precision * float64(support)
+		weighted.Recall += metrics.Recall * float64(support)
+		weighted.F1 += metrics.F1 * float64(support)
+
+		truePositives += tp
+		predicted += predictedAs
+		supported += support
+	}
+
+	if len(classNames) > 0 {
+		macro.Precision /= float64(len(classNames))
+		macro.Recall /= float64(len(classNames))
+		macro.F1 /= float64(len(classNames))
+	}
+
+	if supported > 0 {
+		weighted.Precision /= float64(supported)
+		weighted.Recall /= float64(supported)
+		weighted.F1 /= float64(supported)
+	}
+
+	micro := AverageMetrics{
+		Precision: ratio(truePositives, predicted),
+		Recall:    ratio(truePositives, supported),
+	}
+	micro.F1 = harmonicMean(micro.Precision, micro.Recall)
+
+	analysis.ConfusionMatrix = matrix
+	analysis.MacroAverage, analysis.MicroAverage, analysis.WeightedAverage = &macro, &micro, &weighted
+	analysis.Calibration = trs.Calibration(classNames, DEFAULT_CALIBRATION_BINS)
+	analysis.Agreement = trs.Agreement(classNames)
+	return analysis
+}
+
+// ratio returns n/d, or 0 if d is 0
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
+
+// harmonicMean returns the harmonic mean of a and b, or 0 if both are 0
+func harmonicMean(a, b float64) float64 {
+	if a+b == 0 {
+		return 0
+	}
+	return 2 * a * b / (a + b)
+}
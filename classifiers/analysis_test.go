@@ -0,0 +1,108 @@
+package classifiers_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ScarletTanager/basilisk/classifiers"
+)
+
+var _ = Describe("Analysis", func() {
+	var (
+		classNames []string
+		results    classifiers.TestResults
+	)
+
+	// result creates a test result for a record of the actual class
+	result := func(actual, predicted int) classifiers.TestResult {
+		return classifiers.TestResult{
+			Record:    classifiers.Record{Class: actual},
+			Predicted: predicted,
+		}
+	}
+
+	BeforeEach(func() {
+		classNames = []string{"cat", "dog", "fish"}
+		results = classifiers.TestResults{
+			result(0, 0), result(0, 0), result(0, 1),
+			result(1, 1), result(1, 0), result(1, classifiers.NO_PREDICTION),
+			result(2, 2),
+		}
+	})
+
+	Describe("AnalyzeClasses", func() {
+		It("Includes the counts and accuracy", func() {
+			analysis := results.AnalyzeClasses(classNames)
+			Expect(analysis.ResultCount).To(Equal(7))
+			Expect(analysis.CorrectCount).To(Equal(4))
+		})
+
+		It("Builds the confusion matrix", func() {
+			Expect(results.AnalyzeClasses(classNames).ConfusionMatrix).To(Equal(&classifiers.ConfusionMatrix{
+				Labels: classNames,
+				Counts: [][]int{
+					{2, 1, 0},
+					{1, 1, 0},
+					{0, 0, 1},
+				},
+				Unpredicted: []int{0, 1, 0},
+			}))
+		})
+
+		It("Measures each class", func() {
+			classes := results.AnalyzeClasses(classNames).Classes
+			Expect(classes).To(HaveLen(3))
+
+			Expect(classes[0].Class).To(Equal("cat"))
+			Expect(classes[0].Precision).To(BeNumerically("~", 2.0/3))
+			Expect(classes[0].Recall).To(BeNumerically("~", 2.0/3))
+			Expect(classes[0].F1).To(BeNumerically("~", 2.0/3))
+			Expect(classes[0].Support).To(Equal(3))
+
+			// The unpredicted record counts against the recall, but not the precision
+			Expect(classes[1].Precision).To(BeNumerically("~", .5))
+			Expect(classes[1].Recall).To(BeNumerically("~", 1.0/3))
+			Expect(classes[1].F1).To(BeNumerically("~", .4))
+			Expect(classes[1].Support).To(Equal(3))
+
+			Expect(classes[2]).To(Equal(classifiers.ClassMetrics{Class: "fish", Precision: 1, Recall: 1, F1: 1, Support: 1}))
+		})
+
+		It("Averages the metrics over the classes", func() {
+			analysis := results.AnalyzeClasses(classNames)
+
+			Expect(analysis.MacroAverage.Precision).To(BeNumerically("~", (2.0/3+.5+1)/3))
+			Expect(analysis.MacroAverage.Recall).To(BeNumerically("~", (2.0/3+1.0/3+1)/3))
+			Expect(analysis.MacroAverage.F1).To(BeNumerically("~", (2.0/3+.4+1)/3))
+
+			Expect(analysis.WeightedAverage.Precision).To(BeNumerically("~", (3*2.0/3+3*.5+1)/7))
+			Expect(analysis.WeightedAverage.Recall).To(BeNumerically("~", (3*2.0/3+3*1.0/3+1)/7))
+
+			// 4 correct out of 6 predictions and 7 records
+			Expect(analysis.MicroAverage.Precision).To(BeNumerically("~", 4.0/6))
+			Expect(analysis.MicroAverage.Recall).To(BeNumerically("~", 4.0/7))
+		})
+
+		When("A class is never predicted", func() {
+			BeforeEach(func() {
+				results[6].Predicted = 0
+			})
+
+			It("Reports a precision of 0 rather than dividing by 0", func() {
+				fish := results.AnalyzeClasses(classNames).Classes[2]
+				Expect(fish.Precision).To(BeZero())
+				Expect(fish.Recall).To(BeZero())
+				Expect(fish.F1).To(BeZero())
+			})
+		})
+	})
+
+	Describe("Analyze", func() {
+		It("Leaves out the per-class metrics", func() {
+			analysis := results.Analyze()
+			Expect(analysis.ConfusionMatrix).To(BeNil())
+			Expect(analysis.Classes).To(BeNil())
+			Expect(analysis.MacroAverage).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,102 @@
+package classifiers
+
+import (
+	"math"
+)
+
+const (
+	DEFAULT_CALIBRATION_BINS = 10
+
+	// logLossEpsilon bounds the probabilities used in the log loss away from 0, so that a
+	// single confident mistake does not make the loss infinite
+	logLossEpsilon = 1e-15
+)
+
+// Calibration measures how well the probabilities of the results match the rates at which
+// the predictions are correct
+type Calibration struct {
+	// LogLoss is the mean negative log of the probability given to the actual class (the
+	// probability is clipped to at least 1e-15)
+	LogLoss float64 `json:"log_loss"`
+	// BrierScore is the mean over the results of the sum over the classes of the squared
+	// difference between the probability of the class and 1 (for the actual class) or 0
+	// (for the others).  It ranges from 0 (perfect) to 2.
+	BrierScore float64 `json:"brier_score"`
+	// ReliabilityBins groups the predictions by the probability of the predicted class, into
+	// bins of equal width.  Results for which no prediction was made are left out.
+	ReliabilityBins []ReliabilityBin `json:"reliability_bins"`
+	// ExpectedCalibrationError is the mean, weighted by the number of predictions, of the
+	// difference between the confidence and the accuracy of each bin
+	ExpectedCalibrationError float64 `json:"expected_calibration_error"`
+}
+
+// ReliabilityBin holds the predictions whose probability is in [Lower, Upper) - the last bin
+// includes its upper bound of 1
+type ReliabilityBin struct {
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+	Count int     `json:"count"`
+	// Confidence is the mean probability of the predictions, or 0 if there are none
+	Confidence float64 `json:"confidence"`
+	// Accuracy is the share of the predictions which are correct, or 0 if there are none
+	Accuracy float64 `json:"accuracy"`
+}
+
+// Calibration computes the calibration of the probabilities of the results, with the given
+// number of reliability bins.  classNames must list the names of the classes of the results,
+// as in DataSet.ClassNames.  Returns nil if there are no results or bins.
+func (trs TestResults) Calibration(classNames []string, bins int) *Calibration {
+	if len(trs) == 0 || bins < 1 {
+		return nil
+	}
+
+	calibration := &Calibration{
+		ReliabilityBins: make([]ReliabilityBin, bins),
+	}
+	for b := range calibration.ReliabilityBins {
+		calibration.ReliabilityBins[b].Lower = float64(b) / float64(bins)
+		calibration.ReliabilityBins[b].Upper = float64(b+1) / float64(bins)
+	}
+
+	predictions := 0
+	for _, result := range trs {
+		for c, className := range classNames {
+			p := result.Probabilities[className]
+			if c == result.Class {
+				calibration.LogLoss -= math.Log(math.Max(p, logLossEpsilon))
+				calibration.BrierScore += (1 - p) * (1 - p)
+			} else {
+				calibration.BrierScore += p * p
+			}
+		}
+
+		if result.Predicted == NO_PREDICTION {
+			continue
+		}
+
+		confidence := result.Probabilities[classNames[result.Predicted]]
+		bin := &calibration.ReliabilityBins[min(int(confidence*float64(bins)), bins-1)]
+		bin.Count++
+		bin.Confidence += confidence
+		if result.Predicted == result.Class {
+			bin.Accuracy++
+		}
+		predictions++
+	}
+
+	calibration.LogLoss /= float64(len(trs))
+	calibration.BrierScore /= float64(len(trs))
+
+	for b := range calibration.ReliabilityBins {
+		bin := &calibration.ReliabilityBins[b]
+		if bin.Count == 0 {
+			continue
+		}
+
+		calibration.ExpectedCalibrationError += math.Abs(bin.Accuracy-bin.Confidence) / float64(predictions)
+		bin.Confidence /= float64(bin.Count)
+		bin.Accuracy /= float64(bin.Count)
+	}
+
+	return calibration
+}
@@ -0,0 +1,99 @@
+package classifiers_test
+
+import (
+	"math"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ScarletTanager/basilisk/classifiers"
+)
+
+var _ = Describe("Calibration", func() {
+	var (
+		classNames []string
+		results    classifiers.TestResults
+	)
+
+	// result creates a test result for a record of the actual class, with the given
+	// probability of class a
+	result := func(actual, predicted int, a float64) classifiers.TestResult {
+		return classifiers.TestResult{
+			Record:        classifiers.Record{Class: actual},
+			Predicted:     predicted,
+			Probabilities: map[string]float64{"a": a, "b": 1 - a},
+		}
+	}
+
+	BeforeEach(func() {
+		classNames = []string{"a", "b"}
+		results = classifiers.TestResults{
+			result(0, 0, .8),
+			result(1, 0, .6),
+			result(1, 1, .1),
+			result(0, classifiers.NO_PREDICTION, .5),
+		}
+	})
+
+	It("Computes the log loss", func() {
+		calibration := results.Calibration(classNames, 10)
+		Expect(calibration.LogLoss).To(BeNumerically("~", -(math.Log(.8)+math.Log(.4)+math.Log(.9)+math.Log(.5))/4))
+	})
+
+	It("Computes the Brier score", func() {
+		calibration := results.Calibration(classNames, 10)
+		Expect(calibration.BrierScore).To(BeNumerically("~", (.08+.72+.02+.5)/4))
+	})
+
+	It("Bins the predictions by confidence", func() {
+		bins := results.Calibration(classNames, 10).ReliabilityBins
+		Expect(bins).To(HaveLen(10))
+		Expect(bins[0].Lower).To(Equal(0.0))
+		Expect(bins[9].Upper).To(Equal(1.0))
+
+		for b, bin := range bins {
+			switch b {
+			case 6:
+				Expect(bin.Count).To(Equal(1))
+				Expect(bin.Confidence).To(BeNumerically("~", .6))
+				Expect(bin.Accuracy).To(Equal(0.0))
+			case 8:
+				Expect(bin.Count).To(Equal(1))
+				Expect(bin.Confidence).To(BeNumerically("~", .8))
+				Expect(bin.Accuracy).To(Equal(1.0))
+			case 9:
+				Expect(bin.Count).To(Equal(1))
+				Expect(bin.Confidence).To(BeNumerically("~", .9))
+				Expect(bin.Accuracy).To(Equal(1.0))
+			default:
+				// The unpredicted result is not binned
+				Expect(bin.Count).To(BeZero())
+			}
+		}
+	})
+
+	It("Computes the expected calibration error", func() {
+		Expect(results.Calibration(classNames, 10).ExpectedCalibrationError).To(BeNumerically("~", (.2+.6+.1)/3))
+	})
+
+	It("Puts a probability of 1 in the last bin", func() {
+		results = classifiers.TestResults{result(0, 0, 1)}
+		bins := results.Calibration(classNames, 4).ReliabilityBins
+		Expect(bins[3].Count).To(Equal(1))
+	})
+
+	It("Clips the probability of the actual class in the log loss", func() {
+		results = classifiers.TestResults{result(1, 0, 1)}
+		Expect(results.Calibration(classNames, 10).LogLoss).To(BeNumerically("~", -math.Log(1e-15)))
+	})
+
+	When("There are no results", func() {
+		It("Returns nil", func() {
+			Expect(classifiers.TestResults{}.Calibration(classNames, 10)).To(BeNil())
+		})
+	})
+
+	It("Is included in the analysis of the classes", func() {
+		Expect(results.AnalyzeClasses(classNames).Calibration).To(Equal(results.Calibration(classNames, classifiers.DEFAULT_CALIBRATION_BINS)))
+	})
+})
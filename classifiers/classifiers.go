@@ -1,8 +1,153 @@
 package classifiers
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ScarletTanager/wyvern"
+)
+
 type ClassifierImplementation struct {
 	RawData      *DataSet
 	TrainingData *DataSet
 	TestingData  *DataSet
 	Results      TestResults
 }
+
+// Data returns the training and testing data, in that order
+func (ci *ClassifierImplementation) Data() (*DataSet, *DataSet) {
+	return ci.TrainingData, ci.TestingData
+}
+
+// Raw returns the data the model was given, before it was split into training and testing
+// data (and before the training data was reduced, for KNN models)
+func (ci *ClassifierImplementation) Raw() *DataSet {
+	return ci.RawData
+}
+
+// split divides the dataset into training and testing data, and sets it as the model's data.
+// It fails, leaving the model's data alone, if there are no training records.
+func (ci *ClassifierImplementation) split(ds *DataSet, cfg *DataSplitConfig) error {
+	if ds == nil {
+		return errors.New("Unable to train model, no data")
+	}
+
+	training, testing, err := ds.Split(cfg)
+	if err != nil {
+		return err
+	}
+
+	if len(training.Records) == 0 {
+		return errors.New("Unable to train model, no training records")
+	}
+
+	ci.RawData, ci.TrainingData, ci.TestingData = ds, training, testing
+	return nil
+}
+
+// checkValues verifies that a set of attribute values can be classified by a
+// model trained on the current TrainingData.
+func (ci *ClassifierImplementation) checkValues(values wyvern.Vector[float64]) error {
+	if ci.TrainingData == nil {
+		return errors.New("Model has not been trained")
+	}
+
+	if len(values) != len(ci.TrainingData.AttributeNames) {
+		return fmt.Errorf("Expected %d attribute values %v, found %d",
+			len(ci.TrainingData.AttributeNames), ci.TrainingData.AttributeNames, len(values))
+	}
+
+	return nil
+}
+
+// checkBatch runs checkValues over every member of the batch, identifying the
+// first invalid member in the error.
+func (ci *ClassifierImplementation) checkBatch(batch []wyvern.Vector[float64]) error {
+	for i, values := range batch {
+		if err := ci.checkValues(values); err != nil {
+			return fmt.Errorf("Invalid record at index %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// prediction converts the result of classifying an unlabeled record into a Prediction
+func (ci *ClassifierImplementation) prediction(result TestResult) Prediction {
+	p := Prediction{
+		Class:         result.Predicted,
+		Probability:   result.Probability,
+		Votes:         result.Votes,
+		Probabilities: result.Probabilities,
+		Tied:          result.Tied,
+	}
+
+	if result.Predicted != NO_PREDICTION {
+		p.ClassName = ci.TrainingData.ClassNames[result.Predicted]
+	}
+
+	return p
+}
+
+// classifyAll classifies (or, for regression, predicts the targets of) the records on (up to)
+// the given number of worker goroutines, returning the results in the same order as the
+// records.  classify is passed each record along with its position.  If the context is
+// cancelled, the remaining records are not classified and the context's error is returned.
+// If classify panics, the remaining records are not classified and the panic is returned as
+// an error, rather than ending the process.
+func classifyAll[R, T any](ctx context.Context, records []R, workers int, classify func(R, int) T) ([]T, error) {
+	results := make([]T, len(records))
+	workers = max(1, min(workers, len(records)))
+
+	dispatchCtx, stopDispatch := context.WithCancel(ctx)
+	defer stopDispatch()
+
+	var (
+		failure     error
+		failureOnce sync.Once
+	)
+
+	positions := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					failureOnce.Do(func() {
+						failure = fmt.Errorf("Unable to classify records: %v", r)
+					})
+					stopDispatch()
+				}
+			}()
+
+			for i := range positions {
+				results[i] = classify(records[i], i)
+			}
+		}()
+	}
+
+dispatch:
+	for i := range records {
+		select {
+		case <-dispatchCtx.Done():
+			break dispatch
+		case positions <- i:
+		}
+	}
+	close(positions)
+	wg.Wait()
+
+	if failure != nil {
+		return nil, failure
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
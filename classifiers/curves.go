@@ -0,0 +1,171 @@
+package classifiers
+
+import (
+	"math"
+	"sort"
+)
+
+// ROCPoint is a point on a receiver operating characteristic curve: the rates at which
+// records are predicted to be of the class when those whose probability of the class is at
+// least the threshold are
+type ROCPoint struct {
+	Threshold         float64 `json:"threshold"`
+	FalsePositiveRate float64 `json:"false_positive_rate"`
+	TruePositiveRate  float64 `json:"true_positive_rate"`
+}
+
+// PrecisionRecallPoint is a point on a precision-recall curve, with the same thresholds as
+// the ROC curve
+type PrecisionRecallPoint struct {
+	Threshold float64 `json:"threshold"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+}
+
+// ClassCurves holds the one-vs-rest curves of a single class, in which the records of the
+// class are the positives and all other records the negatives.  Each curve starts with a
+// threshold above every probability, at which nothing is predicted to be of the class, and
+// ends with the lowest probability, at which everything is.
+type ClassCurves struct {
+	Class     string `json:"class"`
+	Positives int    `json:"positives"`
+	Negatives int    `json:"negatives"`
+	// ROC and AUC (the area under the ROC curve) are only computed if there are both
+	// positives and negatives
+	ROC []ROCPoint `json:"roc,omitempty"`
+	AUC float64    `json:"auc"`
+	// PrecisionRecall and AveragePrecision (the mean of the precisions at each threshold,
+	// weighted by the increase in recall) are only computed if there are positives
+	PrecisionRecall  []PrecisionRecallPoint `json:"precision_recall,omitempty"`
+	AveragePrecision float64                `json:"average_precision"`
+}
+
+// Curves holds the curves of every class, and the mean AUC and average precision over the
+// classes for which they are computed
+type Curves struct {
+	Classes               []ClassCurves `json:"classes"`
+	MacroAUC              float64       `json:"macro_auc"`
+	MacroAveragePrecision float64       `json:"macro_average_precision"`
+}
+
+// Curves computes the one-vs-rest ROC and precision-recall curves of each class from the
+// probabilities of the results.  classNames must list the names of the classes of the
+// results, as in DataSet.ClassNames.  A result whose probability of a class is NaN is left
+// out of the curves of that class.
+func (trs TestResults) Curves(classNames []string) Curves {
+	curves := Curves{
+		Classes: make([]ClassCurves, len(classNames)),
+	}
+
+	var aucs, averagePrecisions int
+	for c, className := range classNames {
+		curves.Classes[c] = trs.classCurves(c, className)
+		if curves.Classes[c].ROC != nil {
+			curves.MacroAUC += curves.Classes[c].AUC
+			aucs++
+		}
+
+		if curves.Classes[c].PrecisionRecall != nil {
+			curves.MacroAveragePrecision += curves.Classes[c].AveragePrecision
+			averagePrecisions++
+		}
+	}
+
+	if aucs > 0 {
+		curves.MacroAUC /= float64(aucs)
+	}
+
+	if averagePrecisions > 0 {
+		curves.MacroAveragePrecision /= float64(averagePrecisions)
+	}
+
+	return curves
+}
+
+func (trs TestResults) classCurves(class int, className string) ClassCurves {
+	curves := ClassCurves{Class: className}
+
+	// A NaN probability cannot be ranked, and would never be passed by the threshold
+	order := make([]int, 0, len(trs))
+	for i, r := range trs {
+		if math.IsNaN(r.Probabilities[className]) {
+			continue
+		}
+
+		order = append(order, i)
+		if r.Class == class {
+			curves.Positives++
+		} else {
+			curves.Negatives++
+		}
+	}
+
+	if len(order) == 0 {
+		return curves
+	}
+
+	// Order the results by descending probability of the class
+	sort.SliceStable(order, func(i, j int) bool {
+		return trs[order[i]].Probabilities[className] > trs[order[j]].Probabilities[className]
+	})
+
+	if curves.Positives > 0 && curves.Negatives > 0 {
+		curves.ROC = make([]ROCPoint, 0, len(trs)+1)
+	}
+
+	if curves.Positives > 0 {
+		curves.PrecisionRecall = make([]PrecisionRecallPoint, 0, len(trs)+1)
+	}
+
+	threshold := math.Nextafter(trs[order[0]].Probabilities[className], math.Inf(1))
+	tp, fp := 0, 0
+	for i := 0; ; {
+		if curves.ROC != nil {
+			point := ROCPoint{
+				Threshold:         threshold,
+				FalsePositiveRate: ratio(fp, curves.Negatives),
+				TruePositiveRate:  ratio(tp, curves.Positives),
+			}
+
+			if previous := len(curves.ROC) - 1; previous >= 0 {
+				// Trapezoidal, so that tied probabilities are credited with half of their area
+				prior := curves.ROC[previous]
+				curves.AUC += (point.FalsePositiveRate - prior.FalsePositiveRate) * (point.TruePositiveRate + prior.TruePositiveRate) / 2
+			}
+			curves.ROC = append(curves.ROC, point)
+		}
+
+		if curves.PrecisionRecall != nil {
+			point := PrecisionRecallPoint{
+				Threshold: threshold,
+				Precision: 1,
+				Recall:    ratio(tp, curves.Positives),
+			}
+
+			if tp+fp > 0 {
+				point.Precision = ratio(tp, tp+fp)
+			}
+
+			if previous := len(curves.PrecisionRecall) - 1; previous >= 0 {
+				curves.AveragePrecision += (point.Recall - curves.PrecisionRecall[previous].Recall) * point.Precision
+			}
+			curves.PrecisionRecall = append(curves.PrecisionRecall, point)
+		}
+
+		if i == len(order) {
+			break
+		}
+
+		// Move the threshold down to the next probability, taking in every result which has it
+		threshold = trs[order[i]].Probabilities[className]
+		for ; i < len(order) && trs[order[i]].Probabilities[className] == threshold; i++ {
+			if trs[order[i]].Class == class {
+				tp++
+			} else {
+				fp++
+			}
+		}
+	}
+
+	return curves
+}
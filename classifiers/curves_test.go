@@ -0,0 +1,123 @@
+package classifiers_test
+
+import (
+	"math"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ScarletTanager/basilisk/classifiers"
+)
+
+var _ = Describe("Curves", func() {
+	var (
+		classNames []string
+		results    classifiers.TestResults
+	)
+
+	// result creates a test result for a record of the actual class, with the given
+	// probability of class a
+	result := func(actual int, a float64) classifiers.TestResult {
+		return classifiers.TestResult{
+			Record:        classifiers.Record{Class: actual},
+			Probabilities: map[string]float64{"a": a, "b": 1 - a},
+		}
+	}
+
+	BeforeEach(func() {
+		classNames = []string{"a", "b"}
+		results = classifiers.TestResults{
+			result(0, .9),
+			result(1, .8),
+			result(0, .7),
+			result(1, .2),
+		}
+	})
+
+	It("Computes the ROC curve of each class", func() {
+		a := results.Curves(classNames).Classes[0]
+		Expect(a.Class).To(Equal("a"))
+		Expect(a.Positives).To(Equal(2))
+		Expect(a.Negatives).To(Equal(2))
+
+		Expect(a.ROC).To(HaveLen(5))
+		Expect(a.ROC[0].Threshold).To(BeNumerically(">", .9))
+		rates := make([][2]float64, len(a.ROC))
+		for i, point := range a.ROC {
+			rates[i] = [2]float64{point.FalsePositiveRate, point.TruePositiveRate}
+		}
+		Expect(rates).To(Equal([][2]float64{{0, 0}, {0, .5}, {.5, .5}, {.5, 1}, {1, 1}}))
+		Expect(a.ROC[4].Threshold).To(Equal(.2))
+	})
+
+	It("Computes the area under the ROC curves", func() {
+		curves := results.Curves(classNames)
+		// Three of the four pairs of a positive and a negative are ordered correctly
+		Expect(curves.Classes[0].AUC).To(BeNumerically("~", .75))
+		Expect(curves.Classes[1].AUC).To(BeNumerically("~", .75))
+		Expect(curves.MacroAUC).To(BeNumerically("~", .75))
+	})
+
+	It("Computes the precision-recall curve and average precision of each class", func() {
+		a := results.Curves(classNames).Classes[0]
+		Expect(a.PrecisionRecall).To(HaveLen(5))
+		points := make([][2]float64, len(a.PrecisionRecall))
+		for i, point := range a.PrecisionRecall {
+			points[i] = [2]float64{point.Recall, point.Precision}
+		}
+		Expect(points[:3]).To(Equal([][2]float64{{0, 1}, {.5, 1}, {.5, .5}}))
+		Expect(points[3][0]).To(Equal(1.0))
+		Expect(points[3][1]).To(BeNumerically("~", 2.0/3))
+		Expect(points[4]).To(Equal([2]float64{1, .5}))
+		Expect(a.AveragePrecision).To(BeNumerically("~", .5+.5*2.0/3))
+	})
+
+	When("Positives and negatives have the same probability", func() {
+		BeforeEach(func() {
+			results = classifiers.TestResults{
+				result(0, .5),
+				result(1, .5),
+			}
+		})
+
+		It("Credits the tie with half of the area", func() {
+			Expect(results.Curves(classNames).Classes[0].AUC).To(BeNumerically("~", .5))
+		})
+	})
+
+	When("A probability is NaN", func() {
+		BeforeEach(func() {
+			results = append(results, result(0, math.NaN()))
+		})
+
+		It("Leaves the result out of the curves", func() {
+			a := results.Curves(classNames).Classes[0]
+			Expect(a.Positives).To(Equal(2))
+			Expect(a.ROC).To(HaveLen(5))
+			Expect(a.AUC).To(BeNumerically("~", .75))
+		})
+	})
+
+	When("A class has no records", func() {
+		BeforeEach(func() {
+			classNames = append(classNames, "c")
+		})
+
+		It("Leaves out its curves and averages over the other classes", func() {
+			curves := results.Curves(classNames)
+			Expect(curves.Classes[2].Positives).To(BeZero())
+			Expect(curves.Classes[2].ROC).To(BeNil())
+			Expect(curves.Classes[2].PrecisionRecall).To(BeNil())
+			Expect(curves.MacroAUC).To(BeNumerically("~", .75))
+		})
+	})
+
+	When("A trained model is tested", func() {
+		It("Ranks the records well", func() {
+			knnc, _ := classifiers.NewKnn(5, "")
+			Expect(knnc.TrainFromCSVFile("../datasets/iris.csv", &classifiers.DataSplitConfig{Method: classifiers.SplitRandom})).To(Succeed())
+			results, _ := knnc.Test()
+			Expect(results.Curves(knnc.TrainingData.ClassNames).MacroAUC).To(BeNumerically(">", .9))
+		})
+	})
+})
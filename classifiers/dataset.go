@@ -229,10 +229,18 @@ func (ds *DataSet) Attributes() []string {
 // Passing nil for the config results in a random split with 75% of the records used for training.
 // This does not modify the original DataSet.
 func (ds *DataSet) Split(cfg *DataSplitConfig) (*DataSet, *DataSet, error) {
+	trainingRecords, testRecords := splitRecords(ds.Records, cfg)
+	training, _ := NewDataSet(ds.ClassNames, ds.AttributeNames, trainingRecords)
+	test, _ := NewDataSet(ds.ClassNames, ds.AttributeNames, testRecords)
+	return training, test, nil
+}
+
+// splitRecords divides the records into training and test records as described for Split
+func splitRecords[T any](records []T, cfg *DataSplitConfig) ([]T, []T) {
 	var (
 		trainingShare                float64
 		method                       DataSplitMethod
-		trainingRecords, testRecords []Record
+		trainingRecords, testRecords []T
 	)
 
 	if cfg == nil {
@@ -247,34 +255,65 @@ func (ds *DataSet) Split(cfg *DataSplitConfig) (*DataSet, *DataSet, error) {
 		method = cfg.Method
 	}
 
-	splitPoint := int(float64(len(ds.Records)) * trainingShare)
+	splitPoint := int(float64(len(records)) * trainingShare)
+	if cfg != nil && cfg.TrainingCount > 0 {
+		splitPoint = min(cfg.TrainingCount, len(records))
+	}
 	switch method {
 	case SplitRandom:
-		shuffled := randomShuffle(ds.Records)
+		shuffled := randomShuffle(records)
 		trainingRecords = shuffled[:splitPoint]
 		testRecords = shuffled[splitPoint:]
 	case SplitSequential:
-		trainingRecords = ds.Records[:splitPoint]
-		testRecords = ds.Records[splitPoint:]
+		trainingRecords = records[:splitPoint]
+		testRecords = records[splitPoint:]
+	}
+
+	return trainingRecords, testRecords
+}
+
+// KFold divides the records into k folds of (nearly) equal size, returning a training and a
+// test DataSet for each fold: the test DataSet holds the records of the fold, and the
+// training DataSet all of the other records.  Records are dealt out to the folds in turn, so
+// the folds keep any ordering of the records (e.g. by class) rather than being cut from it.
+// This does not modify the original DataSet.
+func (ds *DataSet) KFold(k int) ([]*DataSet, []*DataSet, error) {
+	if k < 2 || k > len(ds.Records) {
+		return nil, nil, fmt.Errorf("Unable to divide %d records into %d folds", len(ds.Records), k)
+	}
+
+	training := make([]*DataSet, k)
+	test := make([]*DataSet, k)
+	for f := 0; f < k; f++ {
+		trainingRecords := make([]Record, 0, len(ds.Records)-len(ds.Records)/k)
+		testRecords := make([]Record, 0, len(ds.Records)/k+1)
+		for i, r := range ds.Records {
+			if i%k == f {
+				testRecords = append(testRecords, r)
+			} else {
+				trainingRecords = append(trainingRecords, r)
+			}
+		}
+
+		training[f], _ = NewDataSet(ds.ClassNames, ds.AttributeNames, trainingRecords)
+		test[f], _ = NewDataSet(ds.ClassNames, ds.AttributeNames, testRecords)
 	}
 
-	training, _ := NewDataSet(ds.ClassNames, ds.AttributeNames, trainingRecords)
-	test, _ := NewDataSet(ds.ClassNames, ds.AttributeNames, testRecords)
 	return training, test, nil
 }
 
-func randomShuffle(source []Record) []Record {
-	var deck []Record
+func randomShuffle[T any](source []T) []T {
+	var deck []T
 
 	if len(source) > maxRecordCount {
 		// Make a copy, we're not modifying the original record set
 		deck = slices.Clone(source)
 		// Split into subsets (cut the deck into smaller decks), shuffle, then merge (in reverse order)
-		var subdecks [][]Record
+		var subdecks [][]T
 		if len(deck)%maxRecordCount != 0 {
-			subdecks = make([][]Record, (len(deck)/maxRecordCount)+1)
+			subdecks = make([][]T, (len(deck)/maxRecordCount)+1)
 		} else {
-			subdecks = make([][]Record, (len(deck) / maxRecordCount))
+			subdecks = make([][]T, (len(deck) / maxRecordCount))
 		}
 
 		// They say a deck is randomized after seven shuffles...
@@ -319,10 +358,10 @@ func randomShuffle(source []Record) []Record {
 	return deck
 }
 
-func shuffleDeck(source []Record) []Record {
+func shuffleDeck[T any](source []T) []T {
 	maxIdx := int(math.Pow(float64(len(source)), 3))
 
-	randomizedSparse := make([]*Record, maxIdx+1)
+	randomizedSparse := make([]*T, maxIdx+1)
 
 	// Generate and store the list of randomized record indices
 	sortKeys := make([]int, len(source))
@@ -345,7 +384,7 @@ func shuffleDeck(source []Record) []Record {
 	}
 
 	// Condense to get the result
-	randomized := make([]Record, len(source))
+	randomized := make([]T, len(source))
 	slices.Sort(sortKeys)
 	for i, k := range sortKeys {
 		randomized[i] = *(randomizedSparse[k])
@@ -363,5 +402,8 @@ const (
 
 type DataSplitConfig struct {
 	TrainingShare float64
+	// TrainingCount, if positive, is the number of records used for training, in place of
+	// TrainingShare
+	TrainingCount int
 	Method        DataSplitMethod
 }
@@ -342,6 +342,43 @@ var _ = Describe("DataSet", func() {
 			})
 		})
 
+		Describe("KFold", func() {
+			It("Divides the records into folds", func() {
+				training, test, err := ds.KFold(2)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(training).To(HaveLen(2))
+				Expect(test).To(HaveLen(2))
+
+				Expect(test[0].Records).To(Equal([]classifiers.Record{data[0], data[2]}))
+				Expect(test[1].Records).To(Equal([]classifiers.Record{data[1], data[3]}))
+				Expect(training[0].Records).To(Equal(test[1].Records))
+				Expect(training[1].Records).To(Equal(test[0].Records))
+				Expect(training[0].ClassNames).To(Equal(classes))
+				Expect(training[0].AttributeNames).To(Equal(attrs))
+			})
+
+			It("Puts every record in exactly one test fold", func() {
+				training, test, err := ds.KFold(3)
+				Expect(err).NotTo(HaveOccurred())
+
+				all := make([]classifiers.Record, 0)
+				for f := range test {
+					Expect(len(training[f].Records) + len(test[f].Records)).To(Equal(len(data)))
+					all = append(all, test[f].Records...)
+				}
+				Expect(all).To(ConsistOf(data))
+			})
+
+			When("There are fewer than two folds, or more folds than records", func() {
+				It("Returns an error", func() {
+					for _, k := range []int{0, 1, len(data) + 1} {
+						_, _, err := ds.KFold(k)
+						Expect(err).To(HaveOccurred())
+					}
+				})
+			})
+		})
+
 		Describe("Split", func() {
 			var (
 				splitCfg *classifiers.DataSplitConfig
@@ -403,6 +440,18 @@ var _ = Describe("DataSet", func() {
 					})
 				})
 
+				When("With a training count specified", func() {
+					BeforeEach(func() {
+						splitCfg.TrainingShare = .5
+						splitCfg.TrainingCount = 7
+						splitCfg.Method = classifiers.SplitSequential
+					})
+
+					It("Allocates exactly that many records to training data, ignoring the training share", func() {
+						testDataSetSplit(sourceDS, splitCfg)
+					})
+				})
+
 				When("With both training share and method specified", func() {
 					BeforeEach(func() {
 						splitCfg.TrainingShare = .65
@@ -440,6 +489,9 @@ func testDataSetSplit(ds *classifiers.DataSet, cfg *classifiers.DataSplitConfig)
 	}
 
 	trainingRecordCount := int(float64(len(ds.Records)) * trainingShare)
+	if cfg != nil && cfg.TrainingCount > 0 {
+		trainingRecordCount = min(cfg.TrainingCount, len(ds.Records))
+	}
 	testRecordCount := len(ds.Records) - trainingRecordCount
 
 	trainingDS1, testDS1, err := ds.Split(cfg)
@@ -0,0 +1,491 @@
+package classifiers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/ScarletTanager/wyvern"
+)
+
+const (
+	ClassifierType_DecisionTree string = "Decision Tree Classifier"
+
+	SplitCriterion_Gini    = "gini"
+	SplitCriterion_Entropy = "entropy"
+)
+
+// DecisionTreeClassifier is a CART (binary, axis-aligned splits) decision tree
+type DecisionTreeClassifier struct {
+	ClassifierImplementation
+	Configuration DecisionTreeClassifierConfig
+
+	root        *DecisionTreeNode
+	importances []float64
+}
+
+type DecisionTreeClassifierConfig struct {
+	// Criterion is the impurity measure used to choose splits, one of gini (the default) or entropy
+	Criterion string
+	// MaxDepth limits the depth of the tree - 0 means unlimited
+	MaxDepth int
+	// MinSamplesLeaf is the smallest number of training records allowed in a leaf - 0 is treated as 1
+	MinSamplesLeaf int
+}
+
+// DecisionTreeNode is a single node of a learned tree.  A node with a nil Split is a leaf.
+type DecisionTreeNode struct {
+	Split *DecisionTreeSplit `json:"split,omitempty"`
+	// Left holds the records whose value for the split attribute is <= the threshold
+	Left  *DecisionTreeNode `json:"left,omitempty"`
+	Right *DecisionTreeNode `json:"right,omitempty"`
+	// Class is the majority class of the training records which reached the node
+	Class     int     `json:"class"`
+	ClassName string  `json:"class_name"`
+	Samples   int     `json:"samples"`
+	Impurity  float64 `json:"impurity"`
+
+	// counts holds the number of training records of each class which reached the node
+	counts []int
+}
+
+type DecisionTreeSplit struct {
+	Attribute      string  `json:"attribute"`
+	AttributeIndex int     `json:"attribute_index"`
+	Threshold      float64 `json:"threshold"`
+}
+
+// IsLeaf returns true if the node has no children
+func (n *DecisionTreeNode) IsLeaf() bool {
+	return n.Split == nil
+}
+
+func NewDecisionTree(cfg DecisionTreeClassifierConfig) (*DecisionTreeClassifier, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("Unable to create classifier: %w", err)
+	}
+
+	if cfg.Criterion == "" {
+		cfg.Criterion = SplitCriterion_Gini
+	}
+
+	if cfg.MinSamplesLeaf == 0 {
+		cfg.MinSamplesLeaf = 1
+	}
+
+	return &DecisionTreeClassifier{
+		Configuration: cfg,
+	}, nil
+}
+
+func (cfg DecisionTreeClassifierConfig) validate() error {
+	switch cfg.Criterion {
+	case "", SplitCriterion_Gini, SplitCriterion_Entropy:
+	default:
+		return fmt.Errorf("Unknown split criterion %s", cfg.Criterion)
+	}
+
+	if cfg.MaxDepth < 0 {
+		return errors.New("Maximum depth cannot be negative")
+	}
+
+	if cfg.MinSamplesLeaf < 0 {
+		return errors.New("Minimum samples per leaf cannot be negative")
+	}
+
+	return nil
+}
+
+func (dtc *DecisionTreeClassifier) Config() interface{} {
+	return dtc.Configuration
+}
+
+func (dtc *DecisionTreeClassifier) Type() string {
+	return ClassifierType_DecisionTree
+}
+
+// Tree returns the root of the learned tree, or nil if the model has not been trained.
+// The tree can be marshaled directly to JSON.
+func (dtc *DecisionTreeClassifier) Tree() *DecisionTreeNode {
+	return dtc.root
+}
+
+// AttributeImportances returns the share of the total impurity decrease in the tree
+// contributed by splits on each attribute, keyed by attribute name.
+func (dtc *DecisionTreeClassifier) AttributeImportances() map[string]float64 {
+	if dtc.TrainingData == nil {
+		return nil
+	}
+
+	return attributeImportances(dtc.importances, dtc.TrainingData.AttributeNames)
+}
+
+func (dtc *DecisionTreeClassifier) TrainFromCSV(data []byte, cfg *DataSplitConfig) error {
+	ds, err := FromCSV(data)
+	if err != nil {
+		return fmt.Errorf("Error training from CSV: %w", err)
+	}
+
+	return dtc.train(ds, cfg)
+}
+
+func (dtc *DecisionTreeClassifier) TrainFromCSVFile(path string, cfg *DataSplitConfig) error {
+	ds, err := FromCSVFile(path)
+	if err != nil {
+		return fmt.Errorf("Error training from CSV file %s: %w", path, err)
+	}
+
+	return dtc.train(ds, cfg)
+}
+
+func (dtc *DecisionTreeClassifier) TrainFromDataset(ds *DataSet, cfg *DataSplitConfig) error {
+	return dtc.train(ds, cfg)
+}
+
+func (dtc *DecisionTreeClassifier) TrainFromJSON(data []byte, cfg *DataSplitConfig) error {
+	ds, err := FromJSON(data)
+	if err != nil {
+		return fmt.Errorf("Error training from JSON: %w", err)
+	}
+
+	return dtc.train(ds, cfg)
+}
+
+func (dtc *DecisionTreeClassifier) TrainFromJSONFile(path string, cfg *DataSplitConfig) error {
+	ds, err := FromJSONFile(path)
+	if err != nil {
+		return fmt.Errorf("Error training from JSON file: %w", err)
+	}
+
+	return dtc.train(ds, cfg)
+}
+
+func (dtc *DecisionTreeClassifier) Retrain(cfg *DataSplitConfig) error {
+	return dtc.train(dtc.RawData, cfg)
+}
+
+// train splits the data and fits a new model to the training data, replacing this one only
+// if training succeeds
+func (dtc *DecisionTreeClassifier) train(ds *DataSet, cfg *DataSplitConfig) error {
+	trained := &DecisionTreeClassifier{Configuration: dtc.Configuration}
+	if err := trained.split(ds, cfg); err != nil {
+		return err
+	}
+
+	trained.fit()
+	*dtc = *trained
+	return nil
+}
+
+// fit grows the tree from the training data
+func (dtc *DecisionTreeClassifier) fit() {
+
+	tb := newTreeBuilder(dtc.TrainingData, dtc.Configuration, 0, nil)
+	indices := make([]int, len(dtc.TrainingData.Records))
+	for i := range indices {
+		indices[i] = i
+	}
+
+	dtc.root = tb.build(indices, 0)
+	dtc.importances = tb.importances
+}
+
+// Test classifies the records of the testing data
+func (dtc *DecisionTreeClassifier) Test() (TestResults, error) {
+	return dtc.TestContext(context.Background())
+}
+
+// TestContext classifies the records of the testing data, stopping early if the context
+// is cancelled
+func (dtc *DecisionTreeClassifier) TestContext(ctx context.Context) (TestResults, error) {
+	if dtc.TrainingData == nil || dtc.TestingData == nil {
+		return nil, errors.New("Untestable model")
+	}
+
+	results, err := classifyAll(ctx, dtc.TestingData.Records, 1, func(r Record, _ int) TestResult {
+		return dtc.classifyRecord(r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dtc.Results = results
+	return results, nil
+}
+
+// Predict classifies a single unlabeled record, represented by its attribute values.
+// The values must be in the same order as the AttributeNames of the training data.
+func (dtc *DecisionTreeClassifier) Predict(values wyvern.Vector[float64]) (Prediction, error) {
+	if err := dtc.checkValues(values); err != nil {
+		return Prediction{}, err
+	}
+
+	return dtc.prediction(dtc.classifyRecord(Record{Class: NO_PREDICTION, AttributeValues: values})), nil
+}
+
+// PredictBatch classifies each of the unlabeled records in the batch.  If any member
+// of the batch is invalid, no predictions are made.
+func (dtc *DecisionTreeClassifier) PredictBatch(batch []wyvern.Vector[float64]) ([]Prediction, error) {
+	if err := dtc.checkBatch(batch); err != nil {
+		return nil, err
+	}
+
+	predictions := make([]Prediction, len(batch))
+	for i, values := range batch {
+		predictions[i] = dtc.prediction(dtc.classifyRecord(Record{Class: NO_PREDICTION, AttributeValues: values}))
+	}
+
+	return predictions, nil
+}
+
+// classifyRecord predicts the majority class of the leaf the record falls into, with
+// the class distribution of that leaf as the probabilities.
+func (dtc *DecisionTreeClassifier) classifyRecord(r Record) TestResult {
+	leaf := dtc.root.leafFor(r.AttributeValues)
+
+	result := TestResult{
+		Record:        r,
+		Predicted:     leaf.Class,
+		Probabilities: make(map[string]float64, len(dtc.TrainingData.ClassNames)),
+	}
+
+	for ci, className := range dtc.TrainingData.ClassNames {
+		result.Probabilities[className] = float64(leaf.counts[ci]) / float64(leaf.Samples)
+	}
+	result.Probability = result.Probabilities[dtc.TrainingData.ClassNames[leaf.Class]]
+
+	return result
+}
+
+func (n *DecisionTreeNode) leafFor(values wyvern.Vector[float64]) *DecisionTreeNode {
+	node := n
+	for !node.IsLeaf() {
+		if values[node.Split.AttributeIndex] <= node.Split.Threshold {
+			node = node.Left
+		} else {
+			node = node.Right
+		}
+	}
+
+	return node
+}
+
+// treeBuilder grows a tree over (a subset of) the records of a DataSet.  It is shared
+// by the decision tree and random forest classifiers.
+type treeBuilder struct {
+	data           *DataSet
+	impurity       func(counts []int, total int) float64
+	maxDepth       int
+	minSamplesLeaf int
+	// maxFeatures is the number of attributes considered at each split - 0 means all of them
+	maxFeatures int
+	rng         *rand.Rand
+	// importances accumulates the (sample weighted) impurity decrease of each attribute's splits
+	importances []float64
+}
+
+func newTreeBuilder(data *DataSet, cfg DecisionTreeClassifierConfig, maxFeatures int, rng *rand.Rand) *treeBuilder {
+	tb := &treeBuilder{
+		data:           data,
+		impurity:       giniImpurity,
+		maxDepth:       cfg.MaxDepth,
+		minSamplesLeaf: cfg.MinSamplesLeaf,
+		maxFeatures:    maxFeatures,
+		rng:            rng,
+		importances:    make([]float64, len(data.AttributeNames)),
+	}
+
+	if cfg.Criterion == SplitCriterion_Entropy {
+		tb.impurity = entropyImpurity
+	}
+
+	if tb.minSamplesLeaf < 1 {
+		tb.minSamplesLeaf = 1
+	}
+
+	if tb.maxFeatures <= 0 || tb.maxFeatures > len(data.AttributeNames) {
+		tb.maxFeatures = len(data.AttributeNames)
+	}
+
+	return tb
+}
+
+// build grows the subtree for the records at the given indices (which may repeat, as with
+// a bootstrap sample).  The order of indices is not preserved.
+func (tb *treeBuilder) build(indices []int, depth int) *DecisionTreeNode {
+	node := &DecisionTreeNode{
+		Samples: len(indices),
+		counts:  make([]int, len(tb.data.ClassNames)),
+	}
+
+	for _, ri := range indices {
+		node.counts[tb.data.Records[ri].Class]++
+	}
+
+	for ci, count := range node.counts {
+		if count > node.counts[node.Class] {
+			node.Class = ci
+		}
+	}
+	node.ClassName = tb.data.ClassNames[node.Class]
+	node.Impurity = tb.impurity(node.counts, node.Samples)
+
+	if node.Impurity == 0 || (tb.maxDepth > 0 && depth >= tb.maxDepth) || len(indices) < 2*tb.minSamplesLeaf {
+		return node
+	}
+
+	attribute, threshold, decrease, found := tb.bestSplit(indices, node)
+	if !found {
+		return node
+	}
+
+	// Partition the indices in place
+	boundary := 0
+	for i, ri := range indices {
+		if tb.data.Records[ri].AttributeValues[attribute] <= threshold {
+			indices[boundary], indices[i] = indices[i], indices[boundary]
+			boundary++
+		}
+	}
+
+	tb.importances[attribute] += decrease
+	node.Split = &DecisionTreeSplit{
+		Attribute:      tb.data.AttributeNames[attribute],
+		AttributeIndex: attribute,
+		Threshold:      threshold,
+	}
+	node.Left = tb.build(indices[:boundary], depth+1)
+	node.Right = tb.build(indices[boundary:], depth+1)
+
+	return node
+}
+
+// bestSplit finds the attribute and threshold which minimize the weighted impurity of the
+// children.  decrease is the (sample weighted) reduction in impurity achieved by the split.
+func (tb *treeBuilder) bestSplit(indices []int, node *DecisionTreeNode) (int, float64, float64, bool) {
+	var (
+		bestAttribute int
+		bestThreshold float64
+		found         bool
+	)
+
+	parentImpurity := node.Impurity * float64(node.Samples)
+	bestImpurity := parentImpurity
+
+	sorted := make([]int, len(indices))
+	copy(sorted, indices)
+	leftCounts := make([]int, len(node.counts))
+	rightCounts := make([]int, len(node.counts))
+
+	for _, attribute := range tb.candidateAttributes() {
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return tb.data.Records[sorted[i]].AttributeValues[attribute] < tb.data.Records[sorted[j]].AttributeValues[attribute]
+		})
+
+		for ci := range leftCounts {
+			leftCounts[ci] = 0
+		}
+		copy(rightCounts, node.counts)
+
+		for i := 0; i < len(sorted)-1; i++ {
+			class := tb.data.Records[sorted[i]].Class
+			leftCounts[class]++
+			rightCounts[class]--
+
+			value := tb.data.Records[sorted[i]].AttributeValues[attribute]
+			next := tb.data.Records[sorted[i+1]].AttributeValues[attribute]
+			leftSamples := i + 1
+			rightSamples := len(sorted) - leftSamples
+			if value == next || leftSamples < tb.minSamplesLeaf || rightSamples < tb.minSamplesLeaf {
+				continue
+			}
+
+			impurity := tb.impurity(leftCounts, leftSamples)*float64(leftSamples) +
+				tb.impurity(rightCounts, rightSamples)*float64(rightSamples)
+			if impurity < bestImpurity {
+				bestImpurity = impurity
+				bestAttribute = attribute
+				bestThreshold = (value + next) / 2
+				found = true
+			}
+		}
+	}
+
+	return bestAttribute, bestThreshold, parentImpurity - bestImpurity, found
+}
+
+// candidateAttributes returns the indices of the attributes to consider for a split
+func (tb *treeBuilder) candidateAttributes() []int {
+	attributes := make([]int, len(tb.data.AttributeNames))
+	for i := range attributes {
+		attributes[i] = i
+	}
+
+	if tb.maxFeatures == len(attributes) || tb.rng == nil {
+		return attributes
+	}
+
+	// Partial Fisher-Yates shuffle to select maxFeatures attributes without replacement
+	for i := 0; i < tb.maxFeatures; i++ {
+		j := i + tb.rng.Intn(len(attributes)-i)
+		attributes[i], attributes[j] = attributes[j], attributes[i]
+	}
+
+	return attributes[:tb.maxFeatures]
+}
+
+func giniImpurity(counts []int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	impurity := 1.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		impurity -= p * p
+	}
+
+	return impurity
+}
+
+func entropyImpurity(counts []int, total int) float64 {
+	var entropy float64
+	for _, count := range counts {
+		if count > 0 {
+			p := float64(count) / float64(total)
+			entropy -= p * math.Log2(p)
+		}
+	}
+
+	return entropy
+}
+
+// normalizeImportances scales the accumulated impurity decreases so they sum to 1
+func normalizeImportances(decreases []float64) []float64 {
+	var total float64
+	for _, d := range decreases {
+		total += d
+	}
+
+	normalized := make([]float64, len(decreases))
+	if total > 0 {
+		for ai, d := range decreases {
+			normalized[ai] = d / total
+		}
+	}
+
+	return normalized
+}
+
+// attributeImportances labels the normalized impurity decreases with the attribute names
+func attributeImportances(decreases []float64, attributeNames []string) map[string]float64 {
+	importances := make(map[string]float64, len(attributeNames))
+	for ai, importance := range normalizeImportances(decreases) {
+		importances[attributeNames[ai]] = importance
+	}
+
+	return importances
+}
@@ -0,0 +1,229 @@
+package classifiers_test
+
+import (
+	"encoding/json"
+
+	"github.com/ScarletTanager/basilisk/classifiers"
+	"github.com/ScarletTanager/wyvern"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DecisionTree", func() {
+	var (
+		dtc     *classifiers.DecisionTreeClassifier
+		path    string
+		cfg     *classifiers.DataSplitConfig
+		treeCfg classifiers.DecisionTreeClassifierConfig
+	)
+
+	depth := func(n *classifiers.DecisionTreeNode) int {
+		var walk func(*classifiers.DecisionTreeNode) int
+		walk = func(n *classifiers.DecisionTreeNode) int {
+			if n.IsLeaf() {
+				return 0
+			}
+			return 1 + max(walk(n.Left), walk(n.Right))
+		}
+		return walk(n)
+	}
+
+	BeforeEach(func() {
+		treeCfg = classifiers.DecisionTreeClassifierConfig{}
+		path = "../fixtures/students.csv"
+		cfg = &classifiers.DataSplitConfig{
+			Method: classifiers.SplitSequential,
+		}
+	})
+
+	JustBeforeEach(func() {
+		dtc, _ = classifiers.NewDecisionTree(treeCfg)
+	})
+
+	Describe("New", func() {
+		It("Applies the defaults", func() {
+			c, err := classifiers.NewDecisionTree(treeCfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Configuration.Criterion).To(Equal(classifiers.SplitCriterion_Gini))
+			Expect(c.Configuration.MinSamplesLeaf).To(Equal(1))
+		})
+
+		When("The criterion is unknown", func() {
+			BeforeEach(func() {
+				treeCfg.Criterion = "chaos"
+			})
+
+			It("Returns nil and an error", func() {
+				c, err := classifiers.NewDecisionTree(treeCfg)
+				Expect(err).To(HaveOccurred())
+				Expect(c).To(BeNil())
+			})
+		})
+
+		When("The maximum depth is negative", func() {
+			BeforeEach(func() {
+				treeCfg.MaxDepth = -1
+			})
+
+			It("Returns nil and an error", func() {
+				c, err := classifiers.NewDecisionTree(treeCfg)
+				Expect(err).To(HaveOccurred())
+				Expect(c).To(BeNil())
+			})
+		})
+	})
+
+	Describe("Train", func() {
+		var single *classifiers.DataSet
+
+		BeforeEach(func() {
+			ds, err := classifiers.FromCSVFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			// Too few records for the split to leave any for training
+			single, err = classifiers.NewDataSet(ds.ClassNames, ds.AttributeNames, ds.Records[:1])
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		When("The data leaves no training records", func() {
+			It("Returns an error and leaves the model untrained", func() {
+				Expect(dtc.TrainFromDataset(single, cfg)).To(MatchError(ContainSubstring("no training records")))
+				Expect(dtc.RawData).To(BeNil())
+				Expect(dtc.TrainingData).To(BeNil())
+				_, err := dtc.Test()
+				Expect(err).To(HaveOccurred())
+			})
+
+			When("The model has already been trained", func() {
+				JustBeforeEach(func() {
+					Expect(dtc.TrainFromCSVFile(path, cfg)).To(Succeed())
+				})
+
+				It("Returns an error and leaves the model unchanged", func() {
+					trainingData := dtc.TrainingData
+					before, err := dtc.Test()
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(dtc.TrainFromDataset(single, cfg)).To(MatchError(ContainSubstring("no training records")))
+					Expect(dtc.TrainingData).To(BeIdenticalTo(trainingData))
+					after, err := dtc.Test()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(after).To(Equal(before))
+				})
+			})
+		})
+	})
+
+	Describe("Test", func() {
+		When("The model has been trained", func() {
+			JustBeforeEach(func() {
+				Expect(dtc.TrainFromCSVFile(path, cfg)).NotTo(HaveOccurred())
+			})
+
+			It("Classifies the testing records", func() {
+				results, err := dtc.Test()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(results).To(HaveLen(len(dtc.TestingData.Records)))
+				Expect(results.Analyze().Accuracy).To(Equal(1.0))
+			})
+
+			When("The entropy criterion is used", func() {
+				BeforeEach(func() {
+					treeCfg.Criterion = classifiers.SplitCriterion_Entropy
+					// The classes are interleaved, so a sequential split is representative
+					path = "../fixtures/iris_interleaved.csv"
+				})
+
+				It("Classifies most of the testing records correctly", func() {
+					results, _ := dtc.Test()
+					Expect(results.Analyze().Accuracy).To(BeNumerically(">", 0.85))
+				})
+			})
+		})
+
+		When("The model has not been trained", func() {
+			It("Returns an error", func() {
+				_, err := dtc.Test()
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("Tree", func() {
+		BeforeEach(func() {
+			path = "../datasets/iris.csv"
+		})
+
+		JustBeforeEach(func() {
+			Expect(dtc.TrainFromCSVFile(path, cfg)).NotTo(HaveOccurred())
+		})
+
+		It("Splits on the attributes of the training data", func() {
+			root := dtc.Tree()
+			Expect(root.IsLeaf()).To(BeFalse())
+			Expect(dtc.TrainingData.AttributeNames).To(ContainElement(root.Split.Attribute))
+			Expect(root.Samples).To(Equal(len(dtc.TrainingData.Records)))
+			Expect(root.Left.Samples + root.Right.Samples).To(Equal(root.Samples))
+		})
+
+		It("Can be rendered as JSON", func() {
+			b, err := json.Marshal(dtc.Tree())
+			Expect(err).NotTo(HaveOccurred())
+
+			var rendered map[string]interface{}
+			Expect(json.Unmarshal(b, &rendered)).NotTo(HaveOccurred())
+			Expect(rendered).To(HaveKey("split"))
+			Expect(rendered).To(HaveKey("left"))
+			Expect(rendered).To(HaveKey("right"))
+		})
+
+		When("The depth is limited", func() {
+			BeforeEach(func() {
+				treeCfg.MaxDepth = 2
+			})
+
+			It("Does not grow the tree beyond the maximum depth", func() {
+				Expect(depth(dtc.Tree())).To(BeNumerically("<=", 2))
+			})
+		})
+
+		When("The leaf size is limited", func() {
+			BeforeEach(func() {
+				treeCfg.MinSamplesLeaf = 20
+			})
+
+			It("Does not create leaves with fewer records", func() {
+				var walk func(*classifiers.DecisionTreeNode)
+				walk = func(n *classifiers.DecisionTreeNode) {
+					if n.IsLeaf() {
+						Expect(n.Samples).To(BeNumerically(">=", 20))
+						return
+					}
+					walk(n.Left)
+					walk(n.Right)
+				}
+				walk(dtc.Tree())
+			})
+		})
+
+		It("Reports attribute importances which sum to 1", func() {
+			total := 0.0
+			for _, importance := range dtc.AttributeImportances() {
+				total += importance
+			}
+			Expect(total).To(BeNumerically("~", 1.0))
+		})
+	})
+
+	Describe("Predict", func() {
+		JustBeforeEach(func() {
+			Expect(dtc.TrainFromCSVFile(path, cfg)).NotTo(HaveOccurred())
+		})
+
+		It("Predicts the class of the record", func() {
+			p, err := dtc.Predict(wyvern.Vector[float64]{17.5})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.ClassName).To(Equal("High"))
+			Expect(p.Probability).To(Equal(1.0))
+		})
+	})
+})
@@ -0,0 +1,324 @@
+package classifiers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/ScarletTanager/wyvern"
+)
+
+const (
+	DistanceMethod_Euclidean = "euclidean"
+	DistanceMethod_Manhattan = "manhattan"
+	// The Lp distance for a configurable p (p = 1 is manhattan, p = 2 is euclidean)
+	DistanceMethod_Minkowski = "minkowski"
+	// The largest difference over all of the attributes
+	DistanceMethod_Chebyshev = "chebyshev"
+	// One minus the cosine of the angle between the two points
+	DistanceMethod_Cosine = "cosine"
+	// Euclidean distance after decorrelating and scaling the attributes by the covariance
+	// of the training data
+	DistanceMethod_Mahalanobis = "mahalanobis"
+
+	DEFAULT_MINKOWSKI_P = 2.0
+)
+
+type DistanceFunction func(wyvern.Vector[float64], wyvern.Vector[float64]) float64
+
+// DistanceInfo describes a distance method available to KNN classifiers
+type DistanceInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Metric is true if the distance satisfies the triangle inequality, which
+	// IndexMethod_BallTree relies on
+	Metric bool `json:"metric"`
+	// AxisBounded is true if the distance between two points is never less than the
+	// difference of any one of their attributes, which IndexMethod_KDTree relies on
+	AxisBounded bool `json:"axis_bounded"`
+}
+
+type registeredDistance struct {
+	DistanceInfo
+	// function is nil for the built-in methods which are configured per classifier
+	// (DistanceMethod_Minkowski and DistanceMethod_Mahalanobis)
+	function DistanceFunction
+}
+
+var (
+	distanceRegistryLock sync.RWMutex
+	distanceRegistry     = map[string]registeredDistance{
+		DistanceMethod_Euclidean: {
+			DistanceInfo: DistanceInfo{Name: DistanceMethod_Euclidean, Description: "Straight line distance", Metric: true, AxisBounded: true},
+			function:     EuclideanDistance,
+		},
+		DistanceMethod_Manhattan: {
+			DistanceInfo: DistanceInfo{Name: DistanceMethod_Manhattan, Description: "Sum of the attribute differences", Metric: true, AxisBounded: true},
+			function:     ManhattanDistance,
+		},
+		DistanceMethod_Minkowski: {
+			DistanceInfo: DistanceInfo{Name: DistanceMethod_Minkowski, Description: "Lp distance for a configurable p", Metric: true, AxisBounded: true},
+		},
+		DistanceMethod_Chebyshev: {
+			DistanceInfo: DistanceInfo{Name: DistanceMethod_Chebyshev, Description: "Largest attribute difference", Metric: true, AxisBounded: true},
+			function:     ChebyshevDistance,
+		},
+		DistanceMethod_Cosine: {
+			DistanceInfo: DistanceInfo{Name: DistanceMethod_Cosine, Description: "One minus the cosine of the angle between the points"},
+			function:     CosineDistance,
+		},
+		DistanceMethod_Mahalanobis: {
+			DistanceInfo: DistanceInfo{Name: DistanceMethod_Mahalanobis, Description: "Distance scaled by the covariance of the training data", Metric: true},
+		},
+	}
+)
+
+// RegisterDistance makes a distance function available to KNN classifiers (and the REST
+// server) under info.Name.  Names cannot be registered twice, and the built-in methods
+// cannot be replaced.  Set info.Metric and info.AxisBounded only if the function has those
+// properties - the tree indexes return wrong neighbors for functions which do not.
+func RegisterDistance(info DistanceInfo, fn DistanceFunction) error {
+	if info.Name == "" {
+		return errors.New("Unable to register distance, name cannot be empty")
+	}
+
+	if fn == nil {
+		return fmt.Errorf("Unable to register distance %s, function cannot be nil", info.Name)
+	}
+
+	distanceRegistryLock.Lock()
+	defer distanceRegistryLock.Unlock()
+
+	if _, ok := distanceRegistry[info.Name]; ok {
+		return fmt.Errorf("Unable to register distance %s, name is already registered", info.Name)
+	}
+
+	distanceRegistry[info.Name] = registeredDistance{DistanceInfo: info, function: fn}
+	return nil
+}
+
+// DistanceMethods lists the available distance methods, ordered by name
+func DistanceMethods() []DistanceInfo {
+	distanceRegistryLock.RLock()
+	defer distanceRegistryLock.RUnlock()
+
+	methods := make([]DistanceInfo, 0, len(distanceRegistry))
+	for _, rd := range distanceRegistry {
+		methods = append(methods, rd.DistanceInfo)
+	}
+
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Name < methods[j].Name
+	})
+
+	return methods
+}
+
+func lookupDistance(name string) (registeredDistance, bool) {
+	distanceRegistryLock.RLock()
+	defer distanceRegistryLock.RUnlock()
+
+	rd, ok := distanceRegistry[name]
+	return rd, ok
+}
+
+// For all of the distance functions, we're assuming that the vectors have the same
+// dimensionality (number of components).  Don't use these with vectors that
+// don't have the same dimensionality and expect things to "just work."
+
+// EuclideanDistance is (for now) just a convenience method to return
+// euclidean distance - we will probably change the implementation if/when
+// we support attribute types other than float64
+func EuclideanDistance(a, b wyvern.Vector[float64]) float64 {
+	return a.Difference(b).Magnitude()
+}
+
+// ManhattanDistance returns the Manhattan (city block) distance
+// between two points (represented as vectors).
+func ManhattanDistance(a, b wyvern.Vector[float64]) float64 {
+	var distance float64
+	for _, component := range a.Difference(b) {
+		// Remember that we want the magnitude of the difference
+		distance += math.Abs(component)
+	}
+
+	return distance
+}
+
+// MinkowskiDistance returns a function computing the Minkowski (Lp) distance for the
+// given p, which must be at least 1 for the result to be a metric.
+func MinkowskiDistance(p float64) DistanceFunction {
+	return func(a, b wyvern.Vector[float64]) float64 {
+		var sum float64
+		for i, v := range a {
+			sum += math.Pow(math.Abs(v-b[i]), p)
+		}
+
+		return math.Pow(sum, 1/p)
+	}
+}
+
+// ChebyshevDistance returns the largest difference between the components of
+// two points - the limit of the Minkowski distance as p grows.
+func ChebyshevDistance(a, b wyvern.Vector[float64]) float64 {
+	var distance float64
+	for i, v := range a {
+		distance = math.Max(distance, math.Abs(v-b[i]))
+	}
+
+	return distance
+}
+
+// CosineDistance returns one minus the cosine similarity of two points, so it ranges
+// from 0 (same direction) to 2 (opposite directions).  Only the direction of the points
+// matters, not their magnitude.  The origin is treated as being at distance 1 from every
+// other point (and 0 from itself).  Cosine distance does not satisfy the triangle
+// inequality, so it is not a true metric.
+func CosineDistance(a, b wyvern.Vector[float64]) float64 {
+	var dot, aSquared, bSquared float64
+	for i, v := range a {
+		dot += v * b[i]
+		aSquared += v * v
+		bSquared += b[i] * b[i]
+	}
+
+	if aSquared == 0 || bSquared == 0 {
+		if aSquared == bSquared {
+			return 0
+		}
+		return 1
+	}
+
+	return 1 - dot/math.Sqrt(aSquared*bSquared)
+}
+
+// MahalanobisDistance returns a function computing the Mahalanobis distance for the
+// given inverse covariance matrix, sqrt((a-b)' S^-1 (a-b)).
+func MahalanobisDistance(inverseCovariance [][]float64) DistanceFunction {
+	return func(a, b wyvern.Vector[float64]) float64 {
+		diff := a.Difference(b)
+
+		var sum float64
+		for i, row := range inverseCovariance {
+			var rowSum float64
+			for j, v := range row {
+				rowSum += v * diff[j]
+			}
+			sum += diff[i] * rowSum
+		}
+
+		// Rounding can leave the sum fractionally below zero for (nearly) identical points
+		return math.Sqrt(math.Max(sum, 0))
+	}
+}
+
+// MahalanobisDistanceFromData estimates the covariance of the attributes of the DataSet
+// and returns the Mahalanobis distance function using its inverse.  Returns an error if
+// the covariance matrix is singular (e.g. if an attribute is constant, or is a linear
+// combination of the others).
+func MahalanobisDistanceFromData(ds *DataSet) (DistanceFunction, error) {
+	points := make([]wyvern.Vector[float64], len(ds.Records))
+	for i, r := range ds.Records {
+		points[i] = r.AttributeValues
+	}
+
+	return mahalanobisDistanceFromPoints(points, len(ds.AttributeNames))
+}
+
+func mahalanobisDistanceFromPoints(points []wyvern.Vector[float64], dimensions int) (DistanceFunction, error) {
+	inverse, err := invert(covariance(points, dimensions))
+	if err != nil {
+		return nil, err
+	}
+
+	return MahalanobisDistance(inverse), nil
+}
+
+// covariance returns the sample covariance matrix of the points
+func covariance(points []wyvern.Vector[float64], dimensions int) [][]float64 {
+	means := make([]float64, dimensions)
+	for _, p := range points {
+		for a, v := range p {
+			means[a] += v / float64(len(points))
+		}
+	}
+
+	cov := make([][]float64, dimensions)
+	for i := range cov {
+		cov[i] = make([]float64, dimensions)
+	}
+
+	if len(points) < 2 {
+		return cov
+	}
+
+	for _, p := range points {
+		for i := range cov {
+			for j := range cov[i] {
+				cov[i][j] += (p[i] - means[i]) * (p[j] - means[j])
+			}
+		}
+	}
+
+	for i := range cov {
+		for j := range cov[i] {
+			cov[i][j] /= float64(len(points) - 1)
+		}
+	}
+
+	return cov
+}
+
+// invert inverts a square matrix by Gauss-Jordan elimination with partial pivoting
+func invert(m [][]float64) ([][]float64, error) {
+	n := len(m)
+
+	// Work on the augmented matrix [m | I]
+	aug := make([][]float64, n)
+	var scale float64
+	for i := range m {
+		aug[i] = make([]float64, 2*n)
+		copy(aug[i], m[i])
+		aug[i][n+i] = 1
+		for _, v := range m[i] {
+			scale = math.Max(scale, math.Abs(v))
+		}
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(aug[row][col]) > math.Abs(aug[pivot][col]) {
+				pivot = row
+			}
+		}
+
+		if math.Abs(aug[pivot][col]) <= scale*1e-12 {
+			return nil, errors.New("Covariance matrix is singular")
+		}
+		aug[col], aug[pivot] = aug[pivot], aug[col]
+
+		p := aug[col][col]
+		for j := range aug[col] {
+			aug[col][j] /= p
+		}
+
+		for row := range aug {
+			if row != col && aug[row][col] != 0 {
+				f := aug[row][col]
+				for j := range aug[row] {
+					aug[row][j] -= f * aug[col][j]
+				}
+			}
+		}
+	}
+
+	inverse := make([][]float64, n)
+	for i := range aug {
+		inverse[i] = aug[i][n:]
+	}
+
+	return inverse, nil
+}
@@ -0,0 +1,218 @@
+package classifiers_test
+
+import (
+	"fmt"
+	"math"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ScarletTanager/basilisk/classifiers"
+	"github.com/ScarletTanager/wyvern"
+)
+
+var _ = Describe("Distance", func() {
+	var (
+		a, b             wyvern.Vector[float64]
+		expectedDistance float64
+	)
+
+	BeforeEach(func() {
+		a = wyvern.Vector[float64]{
+			5.5, 12.0, 6, -18.3,
+		}
+
+		b = wyvern.Vector[float64]{
+			-3.2, 18.0, 5.5, -27.0,
+		}
+	})
+
+	JustBeforeEach(func() {
+		Expect(a).To(HaveLen(len(b)))
+	})
+
+	Describe("EuclideanDistance", func() {
+		JustBeforeEach(func() {
+			expectedDistance = a.Difference(b).Magnitude()
+		})
+
+		It("Returns the magnitude of the vector difference", func() {
+			Expect(classifiers.EuclideanDistance(a, b)).To(Equal(expectedDistance))
+		})
+	})
+
+	Describe("ManhattanDistance", func() {
+		JustBeforeEach(func() {
+			expectedDistance = 0
+			for i, v := range a {
+				expectedDistance += math.Abs(v - b[i])
+			}
+		})
+
+		It("Returns the city block distance between the two points", func() {
+			Expect(classifiers.ManhattanDistance(a, b)).To(Equal(expectedDistance))
+		})
+	})
+
+	Describe("MinkowskiDistance", func() {
+		It("Returns the manhattan distance when p is 1", func() {
+			Expect(classifiers.MinkowskiDistance(1)(a, b)).To(BeNumerically("~", classifiers.ManhattanDistance(a, b), 1e-9))
+		})
+
+		It("Returns the euclidean distance when p is 2", func() {
+			Expect(classifiers.MinkowskiDistance(2)(a, b)).To(BeNumerically("~", classifiers.EuclideanDistance(a, b), 1e-9))
+		})
+
+		It("Approaches the chebyshev distance as p grows", func() {
+			Expect(classifiers.MinkowskiDistance(64)(a, b)).To(BeNumerically("~", classifiers.ChebyshevDistance(a, b), .1))
+		})
+	})
+
+	Describe("ChebyshevDistance", func() {
+		It("Returns the largest difference between the components", func() {
+			Expect(classifiers.ChebyshevDistance(a, b)).To(BeNumerically("~", 8.7, 1e-9))
+		})
+	})
+
+	Describe("CosineDistance", func() {
+		It("Is zero for points in the same direction", func() {
+			Expect(classifiers.CosineDistance(a, wyvern.Vector[float64]{11, 24, 12, -36.6})).To(BeNumerically("~", 0, 1e-12))
+		})
+
+		It("Is one for orthogonal points", func() {
+			Expect(classifiers.CosineDistance(wyvern.Vector[float64]{1, 0}, wyvern.Vector[float64]{0, 3})).To(Equal(1.0))
+		})
+
+		It("Is two for points in opposite directions", func() {
+			Expect(classifiers.CosineDistance(wyvern.Vector[float64]{1, 2}, wyvern.Vector[float64]{-2, -4})).To(BeNumerically("~", 2, 1e-12))
+		})
+
+		When("One of the points is the origin", func() {
+			It("Is one", func() {
+				Expect(classifiers.CosineDistance(a, make(wyvern.Vector[float64], len(a)))).To(Equal(1.0))
+			})
+		})
+	})
+
+	Describe("MahalanobisDistance", func() {
+		It("Returns the euclidean distance for the identity covariance", func() {
+			identity := [][]float64{{1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}}
+			Expect(classifiers.MahalanobisDistance(identity)(a, b)).To(BeNumerically("~", classifiers.EuclideanDistance(a, b), 1e-9))
+		})
+
+		It("Scales each attribute by its variance", func() {
+			inverse := [][]float64{{.25, 0}, {0, 1}}
+			Expect(classifiers.MahalanobisDistance(inverse)(wyvern.Vector[float64]{0, 0}, wyvern.Vector[float64]{2, 1})).To(BeNumerically("~", math.Sqrt2, 1e-9))
+		})
+	})
+
+	Describe("MahalanobisDistanceFromData", func() {
+		var (
+			ds *classifiers.DataSet
+		)
+
+		BeforeEach(func() {
+			ds, _ = classifiers.NewDataSet([]string{"only"}, []string{"x", "y"}, []classifiers.Record{
+				{AttributeValues: wyvern.Vector[float64]{-2, 0}},
+				{AttributeValues: wyvern.Vector[float64]{2, 0}},
+				{AttributeValues: wyvern.Vector[float64]{0, -1}},
+				{AttributeValues: wyvern.Vector[float64]{0, 1}},
+			})
+		})
+
+		It("Uses the covariance of the data", func() {
+			// x varies twice as much as y, so (2, 0) is as far from the origin as (0, 1)
+			distance, err := classifiers.MahalanobisDistanceFromData(ds)
+			Expect(err).NotTo(HaveOccurred())
+
+			origin := wyvern.Vector[float64]{0, 0}
+			Expect(distance(origin, wyvern.Vector[float64]{2, 0})).To(BeNumerically("~", distance(origin, wyvern.Vector[float64]{0, 1}), 1e-9))
+		})
+
+		When("An attribute is constant", func() {
+			BeforeEach(func() {
+				for i := range ds.Records {
+					ds.Records[i].AttributeValues[1] = 3
+				}
+			})
+
+			It("Returns an error", func() {
+				distance, err := classifiers.MahalanobisDistanceFromData(ds)
+				Expect(err).To(HaveOccurred())
+				Expect(distance).To(BeNil())
+			})
+		})
+	})
+
+	Describe("Registry", func() {
+		It("Lists the built-in distance methods", func() {
+			names := make([]string, 0)
+			for _, info := range classifiers.DistanceMethods() {
+				names = append(names, info.Name)
+			}
+			Expect(names).To(ContainElements(
+				classifiers.DistanceMethod_Euclidean,
+				classifiers.DistanceMethod_Manhattan,
+				classifiers.DistanceMethod_Minkowski,
+				classifiers.DistanceMethod_Chebyshev,
+				classifiers.DistanceMethod_Cosine,
+				classifiers.DistanceMethod_Mahalanobis,
+			))
+		})
+
+		When("A distance is registered", func() {
+			var (
+				info          classifiers.DistanceInfo
+				registrations int
+			)
+
+			BeforeEach(func() {
+				// The registry is global, so each registration needs a unique name
+				registrations++
+				info = classifiers.DistanceInfo{
+					Name:   fmt.Sprintf("wing_weighted_%d", registrations),
+					Metric: true,
+				}
+				Expect(classifiers.RegisterDistance(info, classifiers.ManhattanDistance)).To(Succeed())
+			})
+
+			It("Is listed", func() {
+				Expect(classifiers.DistanceMethods()).To(ContainElement(info))
+			})
+
+			It("Can be used by a KNN classifier", func() {
+				knnc, err := classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+					K:              3,
+					DistanceMethod: info.Name,
+					Index:          classifiers.IndexMethod_BallTree,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(knnc.TrainFromCSVFile("../datasets/iris.csv", nil)).To(Succeed())
+				Expect(knnc.Predict(wyvern.Vector[float64]{5.1, 3.5, 1.4, .2})).To(HaveField("ClassName", "Iris-setosa"))
+			})
+
+			It("Is not used with indexes which rely on properties it lacks", func() {
+				knnc, err := classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+					K:              3,
+					DistanceMethod: info.Name,
+					Index:          classifiers.IndexMethod_KDTree,
+				})
+				Expect(err).To(HaveOccurred())
+				Expect(knnc).To(BeNil())
+			})
+
+			It("Cannot be registered again", func() {
+				Expect(classifiers.RegisterDistance(info, classifiers.EuclideanDistance)).NotTo(Succeed())
+			})
+		})
+
+		It("Does not replace the built-in distance methods", func() {
+			Expect(classifiers.RegisterDistance(classifiers.DistanceInfo{Name: classifiers.DistanceMethod_Euclidean}, classifiers.ManhattanDistance)).NotTo(Succeed())
+		})
+
+		It("Requires a name and a function", func() {
+			Expect(classifiers.RegisterDistance(classifiers.DistanceInfo{}, classifiers.ManhattanDistance)).NotTo(Succeed())
+			Expect(classifiers.RegisterDistance(classifiers.DistanceInfo{Name: "nothing"}, nil)).NotTo(Succeed())
+		})
+	})
+})
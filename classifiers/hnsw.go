@@ -0,0 +1,221 @@
+package classifiers
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+
+	"github.com/ScarletTanager/wyvern"
+)
+
+const (
+	// Approximate search using a hierarchical navigable small world graph
+	IndexMethod_HNSW = "hnsw"
+
+	DEFAULT_HNSW_M               = 16
+	DEFAULT_HNSW_EF_CONSTRUCTION = 200
+	DEFAULT_HNSW_EF_SEARCH       = 50
+)
+
+// HNSWConfig holds the tuning parameters of an HNSW index.  Larger values increase recall at
+// the cost of memory and build/search time.
+type HNSWConfig struct {
+	// M is the number of links made by each point on each layer (twice this on the bottom layer)
+	M int
+	// EfConstruction is the size of the candidate list used when inserting points
+	EfConstruction int
+	// EfSearch is the size of the candidate list used when searching - it is never less than K
+	EfSearch int
+}
+
+func (cfg *HNSWConfig) applyDefaults() {
+	if cfg.M == 0 {
+		cfg.M = DEFAULT_HNSW_M
+	}
+
+	if cfg.EfConstruction == 0 {
+		cfg.EfConstruction = DEFAULT_HNSW_EF_CONSTRUCTION
+	}
+
+	if cfg.EfSearch == 0 {
+		cfg.EfSearch = DEFAULT_HNSW_EF_SEARCH
+	}
+}
+
+// hnswIndex is a hierarchical navigable small world graph (Malkov & Yashunin).  Every point
+// is on the bottom layer, and each higher layer holds an exponentially smaller subset of the
+// points, so searches can take long strides near the top before refining near the bottom.
+type hnswIndex struct {
+	indexedPoints
+	cfg HNSWConfig
+	rng *rand.Rand
+	// levelFactor normalizes the random level assignment
+	levelFactor float64
+	// links is indexed by point, then by layer
+	links [][][]int
+	// duplicates holds, by point, the points at a distance of 0 from it, which are kept out of
+	// the graph - pruning cannot choose between them, and would leave most of them unreachable
+	duplicates map[int][]int
+	entryPoint int
+	topLayer   int
+}
+
+func newHNSWIndex(ip indexedPoints, cfg HNSWConfig, seed int64) *hnswIndex {
+	h := &hnswIndex{
+		cfg:         cfg,
+		rng:         rand.New(rand.NewSource(seed)),
+		levelFactor: 1 / math.Log(float64(max(cfg.M, 2))),
+		duplicates:  make(map[int][]int),
+		entryPoint:  -1,
+	}
+
+	points := ip.points
+	classes := ip.classes
+	h.indexedPoints = indexedPoints{distance: ip.distance}
+	for i := range points {
+		h.insert(points[i], classes[i])
+	}
+
+	return h
+}
+
+// insert adds a point to the graph, linking it to its nearest neighbors on each of its layers,
+// or records it as a duplicate of a point already in the graph
+func (h *hnswIndex) insert(point wyvern.Vector[float64], class int) {
+	h.points = append(h.points, point)
+	h.classes = append(h.classes, class)
+	i := len(h.points) - 1
+
+	layer := int(-math.Log(1-h.rng.Float64()) * h.levelFactor)
+	if h.entryPoint == -1 {
+		h.links = append(h.links, make([][]int, layer+1))
+		h.entryPoint, h.topLayer = i, layer
+		return
+	}
+
+	entry := []Neighbor{h.neighbor(point, h.entryPoint)}
+	for l := h.topLayer; l > layer; l-- {
+		entry = h.searchLayer(point, entry, 1, l)
+	}
+
+	// Search every layer the point will be on before linking it, so that a duplicate is
+	// found on the bottom layer before it is linked on any other
+	candidates := make([][]Neighbor, min(layer, h.topLayer)+1)
+	for l := len(candidates) - 1; l >= 0; l-- {
+		candidates[l] = h.searchLayer(point, entry, h.cfg.EfConstruction, l)
+		entry = candidates[l]
+	}
+
+	if nearest := candidates[0][0]; nearest.Distance == 0 {
+		h.links = append(h.links, nil)
+		h.duplicates[nearest.Index] = append(h.duplicates[nearest.Index], i)
+		return
+	}
+
+	h.links = append(h.links, make([][]int, layer+1))
+	for l, layerCandidates := range candidates {
+		for _, c := range layerCandidates[:min(h.cfg.M, len(layerCandidates))] {
+			h.links[i][l] = append(h.links[i][l], c.Index)
+			h.links[c.Index][l] = append(h.links[c.Index][l], i)
+			h.prune(c.Index, l)
+		}
+	}
+
+	if layer > h.topLayer {
+		h.entryPoint, h.topLayer = i, layer
+	}
+}
+
+// prune drops the farthest links of a point which has more than the maximum on the layer
+func (h *hnswIndex) prune(i, layer int) {
+	maxLinks := h.cfg.M
+	if layer == 0 {
+		maxLinks = 2 * h.cfg.M
+	}
+
+	if len(h.links[i][layer]) <= maxLinks {
+		return
+	}
+
+	kept := make(neighborHeap, 0, maxLinks)
+	for _, j := range h.links[i][layer] {
+		kept.offer(h.neighbor(h.points[i], j), maxLinks)
+	}
+
+	links := h.links[i][layer][:0]
+	for _, n := range kept.sorted() {
+		links = append(links, n.Index)
+	}
+	h.links[i][layer] = links
+}
+
+// searchLayer returns (up to) the ef points on the layer nearest to the query, nearest first,
+// found by a best-first traversal from the entry points
+func (h *hnswIndex) searchLayer(query wyvern.Vector[float64], entry []Neighbor, ef, layer int) []Neighbor {
+	visited := make(map[int]bool, ef*4)
+	candidates := make(nearestFirstHeap, 0, ef)
+	found := make(neighborHeap, 0, ef)
+
+	for _, e := range entry {
+		visited[e.Index] = true
+		heap.Push(&candidates, e)
+		found.offer(e, ef)
+	}
+
+	for len(candidates) > 0 {
+		c := heap.Pop(&candidates).(Neighbor)
+		if c.Distance > found.bound(ef) {
+			break
+		}
+
+		for _, j := range h.links[c.Index][layer] {
+			if visited[j] {
+				continue
+			}
+			visited[j] = true
+
+			n := h.neighbor(query, j)
+			if n.Distance <= found.bound(ef) {
+				heap.Push(&candidates, n)
+				found.offer(n, ef)
+			}
+		}
+	}
+
+	return found.sorted()
+}
+
+func (h *hnswIndex) nearest(query wyvern.Vector[float64], k int) []Neighbor {
+	if h.entryPoint == -1 {
+		return nil
+	}
+
+	entry := []Neighbor{h.neighbor(query, h.entryPoint)}
+	for l := h.topLayer; l > 0; l-- {
+		entry = h.searchLayer(query, entry, 1, l)
+	}
+
+	nearest := make(neighborHeap, 0, k)
+	for _, n := range h.searchLayer(query, entry, max(h.cfg.EfSearch, k), 0) {
+		nearest.offer(n, k)
+		for _, d := range h.duplicates[n.Index] {
+			nearest.offer(h.neighbor(query, d), k)
+		}
+	}
+
+	return nearest.sorted()
+}
+
+// nearestFirstHeap is a min-heap of neighbors, used for the best-first traversal
+type nearestFirstHeap []Neighbor
+
+func (h nearestFirstHeap) Len() int           { return len(h) }
+func (h nearestFirstHeap) Less(i, j int) bool { return neighborBefore(h[i], h[j]) }
+func (h nearestFirstHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *nearestFirstHeap) Push(x any)        { *h = append(*h, x.(Neighbor)) }
+func (h *nearestFirstHeap) Pop() any {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
@@ -1,30 +1,115 @@
 package classifiers
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"sort"
+	"math"
+	"math/rand"
+	"runtime"
+	"sync"
 
 	"github.com/ScarletTanager/sphinx/probability"
+	"github.com/ScarletTanager/wyvern"
+	"golang.org/x/exp/slices"
 )
 
 type KNearestNeighborClassifier struct {
 	ClassifierImplementation
 	Configuration KNearestNeighborClassifierConfig
+
+	// lock guards the data, configuration and index, so that records can be added (or the
+	// model retrained) while it is being tested or making predictions
+	lock  sync.RWMutex
+	index neighborIndex
+	// attributeScale holds the AttributeWeights in attribute order, nil if there are none
+	attributeScale []float64
 }
 
 type KNearestNeighborClassifierConfig struct {
-	K                int
-	DistanceMethod   string
+	// K is the number of neighbors which vote - it is ignored (and replaced when the model is
+	// trained) if AutoK is set
+	K int
+	// AutoK, if set, selects K by cross-validation on the training data
+	AutoK *AutoKConfig
+	// DistanceMethod is one of the DistanceMethod_ values or a name registered with
+	// RegisterDistance, empty means DistanceMethod_Euclidean
+	DistanceMethod string
+	// MinkowskiP is the p used by DistanceMethod_Minkowski - it must be at least 1, 0 means
+	// DEFAULT_MINKOWSKI_P
+	MinkowskiP float64
+	// Weighting determines how much each of the K neighbors' votes counts - one of the
+	// Weighting_ values, empty means Weighting_Uniform
+	Weighting string
+	// Bandwidth is the width of the gaussian kernel used by Weighting_Gaussian - 0 means
+	// the distance to the Kth nearest neighbor
+	Bandwidth float64
+	// TieBreak determines the prediction when two or more classes receive the same (highest)
+	// share of the vote - one of the TieBreak_ values, empty means TieBreak_LowestIndex
+	TieBreak string
+	// Seed seeds TieBreak_Random and the construction of IndexMethod_HNSW indexes
+	Seed int64
+	// Index is the method used to search for neighbors - one of the IndexMethod_ values,
+	// empty means IndexMethod_BruteForce
+	Index string
+	// HNSW tunes IndexMethod_HNSW indexes - zero values are replaced with the defaults
+	HNSW HNSWConfig
+	// Concurrency is the number of test records classified in parallel - zero means GOMAXPROCS
+	Concurrency int
+	// AttributeWeights scales the attributes, keyed by attribute name, before distances are
+	// computed, so that an attribute with a weight of 2 counts twice as much in the distance
+	// as it would otherwise.  Attributes which are not listed have a weight of 1.
+	AttributeWeights map[string]float64
+	// Reduction, if set, reduces the training data once the model has been trained
+	Reduction        *ReductionConfig
 	distanceFunction DistanceFunction
 }
 
 func (knnc *KNearestNeighborClassifier) Config() interface{} {
+	knnc.lock.RLock()
+	defer knnc.lock.RUnlock()
 	return knnc.Configuration
 }
 
+// Data returns the training and testing data, in that order
+func (knnc *KNearestNeighborClassifier) Data() (*DataSet, *DataSet) {
+	knnc.lock.RLock()
+	defer knnc.lock.RUnlock()
+	return knnc.ClassifierImplementation.Data()
+}
+
+// Raw returns the data the model was given, before it was split into training and testing
+// data and before the training data was reduced
+func (knnc *KNearestNeighborClassifier) Raw() *DataSet {
+	knnc.lock.RLock()
+	defer knnc.lock.RUnlock()
+	return knnc.ClassifierImplementation.Raw()
+}
+
 const (
 	ClassifierType_KNearestNeighbor string = "KNearestNeighbors Classifier"
+
+	// Every neighbor's vote counts equally
+	Weighting_Uniform = "uniform"
+	// Votes are weighted by 1/distance
+	Weighting_InverseDistance = "inverse_distance"
+	// Votes are weighted by 1/distance^2
+	Weighting_InverseSquaredDistance = "inverse_squared_distance"
+	// Votes are weighted by exp(-distance^2 / (2 * bandwidth^2))
+	Weighting_Gaussian = "gaussian"
+
+	// The tied class which comes first in the DataSet's ClassNames wins
+	TieBreak_LowestIndex = "lowest_index"
+	// The tied class of the nearest neighbor wins
+	TieBreak_Nearest = "nearest"
+	// The tied class whose voting neighbors have the lowest total distance wins
+	TieBreak_LowestTotalDistance = "lowest_total_distance"
+	// A tied class is chosen at random (reproducibly, given the Seed)
+	TieBreak_Random = "random"
+	// K is increased until the tie is broken
+	TieBreak_ExpandK = "expand_k"
+	// No prediction (NO_PREDICTION) is made
+	TieBreak_None = "none"
 )
 
 func (knnc *KNearestNeighborClassifier) Type() string {
@@ -32,48 +117,127 @@ func (knnc *KNearestNeighborClassifier) Type() string {
 }
 
 func NewKnn(k int, distanceMethod string) (*KNearestNeighborClassifier, error) {
-	if k <= 0 {
+	return NewKnnFromConfig(KNearestNeighborClassifierConfig{K: k, DistanceMethod: distanceMethod})
+}
+
+// NewKnnFromConfig creates a classifier with the full set of KNN options
+func NewKnnFromConfig(cfg KNearestNeighborClassifierConfig) (*KNearestNeighborClassifier, error) {
+	if cfg.AutoK != nil {
+		autoK := *cfg.AutoK
+		if err := autoK.validate(); err != nil {
+			return nil, fmt.Errorf("Unable to create classifier, %w", err)
+		}
+		cfg.AutoK = &autoK
+		// Until the model is trained
+		cfg.K = autoK.MinK
+	} else if cfg.K <= 0 {
 		return nil, errors.New("Unable to create classifier, k must be greater than 0")
 	}
 
-	var distanceFunc DistanceFunction
+	if cfg.Reduction != nil {
+		reduction := *cfg.Reduction
+		if err := reduction.validate(); err != nil {
+			return nil, fmt.Errorf("Unable to create classifier, %w", err)
+		}
+		cfg.Reduction = &reduction
+	}
 
-	switch distanceMethod {
-	case DistanceMethod_Euclidean:
-		distanceFunc = EuclideanDistance
-	case DistanceMethod_Manhattan:
-		distanceFunc = ManhattanDistance
+	if cfg.DistanceMethod == "" {
+		cfg.DistanceMethod = DistanceMethod_Euclidean
+	}
+
+	distance, ok := lookupDistance(cfg.DistanceMethod)
+	if !ok {
+		return nil, fmt.Errorf("Unable to create classifier, unknown distance method %s", cfg.DistanceMethod)
+	}
+
+	switch cfg.DistanceMethod {
+	case DistanceMethod_Minkowski:
+		if cfg.MinkowskiP == 0 {
+			cfg.MinkowskiP = DEFAULT_MINKOWSKI_P
+		} else if cfg.MinkowskiP < 1 {
+			return nil, errors.New("Unable to create classifier, minkowski p must be at least 1")
+		}
+		cfg.distanceFunction = MinkowskiDistance(cfg.MinkowskiP)
+	case DistanceMethod_Mahalanobis:
+		// The covariance is estimated from the training data when the model is trained
+	default:
+		cfg.distanceFunction = distance.function
+	}
+
+	switch cfg.Weighting {
+	case "":
+		cfg.Weighting = Weighting_Uniform
+	case Weighting_Uniform, Weighting_InverseDistance, Weighting_InverseSquaredDistance, Weighting_Gaussian:
+	default:
+		return nil, fmt.Errorf("Unable to create classifier, unknown weighting %s", cfg.Weighting)
+	}
+
+	switch cfg.TieBreak {
+	case "":
+		cfg.TieBreak = TieBreak_LowestIndex
+	case TieBreak_LowestIndex, TieBreak_Nearest, TieBreak_LowestTotalDistance, TieBreak_Random, TieBreak_ExpandK, TieBreak_None:
 	default:
-		distanceMethod = DistanceMethod_Euclidean
-		distanceFunc = EuclideanDistance
+		return nil, fmt.Errorf("Unable to create classifier, unknown tie-break policy %s", cfg.TieBreak)
+	}
+
+	if cfg.Concurrency < 0 {
+		return nil, errors.New("Unable to create classifier, concurrency cannot be negative")
+	} else if cfg.Concurrency == 0 {
+		cfg.Concurrency = runtime.GOMAXPROCS(0)
+	}
+
+	switch cfg.Index {
+	case "":
+		cfg.Index = IndexMethod_BruteForce
+	case IndexMethod_BruteForce:
+	case IndexMethod_BallTree:
+		if !distance.Metric {
+			return nil, fmt.Errorf("Unable to create classifier, %s indexes cannot be used with %s distance", cfg.Index, cfg.DistanceMethod)
+		}
+	case IndexMethod_HNSW:
+		if cfg.HNSW.M < 0 || cfg.HNSW.EfConstruction < 0 || cfg.HNSW.EfSearch < 0 {
+			return nil, errors.New("Unable to create classifier, HNSW parameters cannot be negative")
+		}
+		cfg.HNSW.applyDefaults()
+	case IndexMethod_KDTree:
+		if !distance.AxisBounded {
+			return nil, fmt.Errorf("Unable to create classifier, %s indexes cannot be used with %s distance", cfg.Index, cfg.DistanceMethod)
+		}
+	default:
+		return nil, fmt.Errorf("Unable to create classifier, unknown index method %s", cfg.Index)
+	}
+
+	for name, weight := range cfg.AttributeWeights {
+		if weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return nil, fmt.Errorf("Unable to create classifier, invalid weight %f for attribute %s", weight, name)
+		}
+	}
+
+	if cfg.Bandwidth < 0 {
+		return nil, errors.New("Unable to create classifier, bandwidth cannot be negative")
 	}
 
 	return &KNearestNeighborClassifier{
-		Configuration: KNearestNeighborClassifierConfig{K: k, DistanceMethod: distanceMethod, distanceFunction: distanceFunc},
+		Configuration: cfg,
 	}, nil
 }
 
-func (knnc *KNearestNeighborClassifier) Data() (*DataSet, *DataSet) {
-	return knnc.TrainingData, knnc.TestingData
-}
-
 func (knnc *KNearestNeighborClassifier) TrainFromCSV(data []byte, cfg *DataSplitConfig) error {
 	return nil
 }
 
 func (knnc *KNearestNeighborClassifier) TrainFromCSVFile(path string, cfg *DataSplitConfig) error {
-	var err error
-	knnc.RawData, err = FromCSVFile(path)
+	ds, err := FromCSVFile(path)
 	if err != nil {
 		return fmt.Errorf("Error training from CSV file %s: %w", path, err)
 	}
 
-	return knnc.train(cfg)
+	return knnc.train(ds, cfg)
 }
 
 func (knnc *KNearestNeighborClassifier) TrainFromDataset(ds *DataSet, cfg *DataSplitConfig) error {
-	knnc.RawData = ds
-	return knnc.train(cfg)
+	return knnc.train(ds, cfg)
 }
 
 func (knnc *KNearestNeighborClassifier) TrainFromJSON(data []byte, cfg *DataSplitConfig) error {
@@ -81,54 +245,480 @@ func (knnc *KNearestNeighborClassifier) TrainFromJSON(data []byte, cfg *DataSpli
 }
 
 func (knnc *KNearestNeighborClassifier) TrainFromJSONFile(path string, cfg *DataSplitConfig) error {
-	var err error
-	knnc.RawData, err = FromJSONFile(path)
+	ds, err := FromJSONFile(path)
 	if err != nil {
 		return fmt.Errorf("Error training from JSON file: %w", err)
 	}
 
-	return knnc.train(cfg)
+	return knnc.train(ds, cfg)
+}
+
+// train splits the data and fits a new model to the training data, replacing this one only
+// if training succeeds
+func (knnc *KNearestNeighborClassifier) train(ds *DataSet, cfg *DataSplitConfig) error {
+	knnc.lock.RLock()
+	trained := &KNearestNeighborClassifier{Configuration: knnc.Configuration.clone()}
+	knnc.lock.RUnlock()
+
+	if err := trained.split(ds, cfg); err != nil {
+		return err
+	}
+
+	if trained.Configuration.AutoK != nil {
+		if err := trained.selectK(); err != nil {
+			return fmt.Errorf("Unable to train classifier: %w", err)
+		}
+	}
+
+	if err := trained.fit(); err != nil {
+		return err
+	}
+
+	if trained.Configuration.Reduction != nil {
+		if err := trained.reduce(); err != nil {
+			return fmt.Errorf("Unable to train classifier: %w", err)
+		}
+	}
+
+	knnc.lock.Lock()
+	defer knnc.lock.Unlock()
+	knnc.ClassifierImplementation = trained.ClassifierImplementation
+	knnc.Configuration = trained.Configuration
+	knnc.index, knnc.attributeScale = trained.index, trained.attributeScale
+	return nil
 }
 
-func (knnc *KNearestNeighborClassifier) train(cfg *DataSplitConfig) error {
+// clone copies the configuration, including the AutoK and Reduction settings which training
+// fills in
+func (cfg KNearestNeighborClassifierConfig) clone() KNearestNeighborClassifierConfig {
+	if cfg.AutoK != nil {
+		autoK := *cfg.AutoK
+		cfg.AutoK = &autoK
+	}
+
+	if cfg.Reduction != nil {
+		reduction := *cfg.Reduction
+		cfg.Reduction = &reduction
+	}
+
+	return cfg
+}
+
+// fit prepares the TrainingData for neighbor searches
+func (knnc *KNearestNeighborClassifier) fit() error {
 	var err error
-	knnc.TrainingData, knnc.TestingData, err = knnc.RawData.Split(cfg)
-	return err
+	if knnc.attributeScale, err = knnc.Configuration.attributeScale(knnc.TrainingData.AttributeNames); err != nil {
+		return fmt.Errorf("Unable to train classifier: %w", err)
+	}
+
+	ip := knnc.indexedPoints()
+	if knnc.Configuration.DistanceMethod == DistanceMethod_Mahalanobis {
+		// Estimated from the unweighted attributes - the covariance of the weighted ones would
+		// undo the weights, and be singular if any weight is 0
+		unweighted := make([]wyvern.Vector[float64], len(knnc.TrainingData.Records))
+		for i, r := range knnc.TrainingData.Records {
+			unweighted[i] = r.AttributeValues
+		}
+
+		if ip.distance, err = mahalanobisDistanceFromPoints(unweighted, len(knnc.TrainingData.AttributeNames)); err != nil {
+			return fmt.Errorf("Unable to train classifier: %w", err)
+		}
+		knnc.Configuration.distanceFunction = ip.distance
+	}
+
+	knnc.buildIndex(ip)
+	return nil
+}
+
+// attributeScale orders the AttributeWeights by attribute.  Returns nil if there are no
+// weights, and an error if a weight is given for an attribute which is not in the data.
+func (cfg KNearestNeighborClassifierConfig) attributeScale(attributeNames []string) ([]float64, error) {
+	if len(cfg.AttributeWeights) == 0 {
+		return nil, nil
+	}
+
+	scale := make([]float64, len(attributeNames))
+	for i, name := range attributeNames {
+		scale[i] = 1
+		if weight, ok := cfg.AttributeWeights[name]; ok {
+			scale[i] = weight
+		}
+	}
+
+	for name := range cfg.AttributeWeights {
+		if !slices.Contains(attributeNames, name) {
+			return nil, fmt.Errorf("Weighted attribute %s is not in the data", name)
+		}
+	}
+
+	return scale, nil
+}
+
+// scaled applies the attribute weights (if any) to the values
+func (knnc *KNearestNeighborClassifier) scaled(values wyvern.Vector[float64]) wyvern.Vector[float64] {
+	if knnc.attributeScale == nil {
+		return values
+	}
+
+	scaled := make(wyvern.Vector[float64], len(values))
+	for i, v := range values {
+		scaled[i] = v * knnc.attributeScale[i]
+	}
+
+	return scaled
+}
+
+// indexedPoints collects the training data in the form used by the neighbor indexes
+func (knnc *KNearestNeighborClassifier) indexedPoints() indexedPoints {
+	ip := indexedPoints{
+		points:   make([]wyvern.Vector[float64], len(knnc.TrainingData.Records)),
+		classes:  make([]int, len(knnc.TrainingData.Records)),
+		distance: knnc.Configuration.distanceFunction,
+	}
+
+	for i, r := range knnc.TrainingData.Records {
+		ip.points[i] = knnc.scaled(r.AttributeValues)
+		ip.classes[i] = r.Class
+	}
+
+	return ip
+}
+
+// buildIndex indexes the training points for neighbor searches using the configured method
+func (knnc *KNearestNeighborClassifier) buildIndex(ip indexedPoints) {
+	if len(ip.points) == 0 {
+		knnc.index = &bruteForceIndex{indexedPoints: ip}
+		return
+	}
+
+	switch knnc.Configuration.Index {
+	case IndexMethod_KDTree:
+		knnc.index = newKDTreeIndex(ip)
+	case IndexMethod_BallTree:
+		knnc.index = newBallTreeIndex(ip)
+	case IndexMethod_HNSW:
+		knnc.index = newHNSWIndex(ip, knnc.Configuration.HNSW, knnc.Configuration.Seed)
+	default:
+		knnc.index = &bruteForceIndex{indexedPoints: ip}
+	}
 }
 
 func (knnc *KNearestNeighborClassifier) Retrain(cfg *DataSplitConfig) error {
-	return knnc.train(cfg)
+	return knnc.train(knnc.Raw(), cfg)
+}
+
+// AddRecords adds records to the training data of a trained model, inserting them into the
+// neighbor index rather than resplitting the data and rebuilding the index.  The records must
+// have a value for every attribute of the training data, and a class which the model already
+// knows.  The attribute weights are applied to the new records, but the covariance used by
+// mahalanobis distance is not re-estimated, nor is K reselected if it was chosen automatically
+// - retrain the model for that.
+func (knnc *KNearestNeighborClassifier) AddRecords(records []Record) error {
+	knnc.lock.Lock()
+	defer knnc.lock.Unlock()
+	return knnc.addRecords(records)
 }
 
+func (knnc *KNearestNeighborClassifier) addRecords(records []Record) error {
+	if knnc.index == nil {
+		return errors.New("Model has not been trained")
+	}
+
+	for i, r := range records {
+		if err := knnc.checkValues(r.AttributeValues); err != nil {
+			return fmt.Errorf("Invalid record at index %d: %w", i, err)
+		}
+
+		if r.Class < 0 || r.Class >= len(knnc.TrainingData.ClassNames) {
+			return fmt.Errorf("Invalid record at index %d: unknown class %d", i, r.Class)
+		}
+	}
+
+	// The records of the training data may share their backing array with the raw data (or
+	// the caller's data), so clip them to make sure that appending copies them
+	knnc.TrainingData, _ = NewDataSet(knnc.TrainingData.ClassNames, knnc.TrainingData.AttributeNames,
+		append(slices.Clip(knnc.TrainingData.Records), records...))
+	knnc.RawData, _ = NewDataSet(knnc.RawData.ClassNames, knnc.RawData.AttributeNames,
+		append(slices.Clip(knnc.RawData.Records), records...))
+
+	for _, r := range records {
+		knnc.index.insert(knnc.scaled(r.AttributeValues), r.Class)
+	}
+
+	return nil
+}
+
+// AddDataset adds the records of the dataset to the training data of a trained model, like
+// AddRecords.  The dataset must have the same attributes (in the same order) as the training
+// data, but its classes are matched to those of the model by name, so it may list them in a
+// different order, or only list some of them.
+func (knnc *KNearestNeighborClassifier) AddDataset(ds *DataSet) error {
+	knnc.lock.Lock()
+	defer knnc.lock.Unlock()
+
+	if knnc.index == nil {
+		return errors.New("Model has not been trained")
+	}
+
+	if !slices.Equal(ds.AttributeNames, knnc.TrainingData.AttributeNames) {
+		return fmt.Errorf("Expected attributes %v, found %v", knnc.TrainingData.AttributeNames, ds.AttributeNames)
+	}
+
+	classes := make([]int, len(ds.ClassNames))
+	for i, className := range ds.ClassNames {
+		if classes[i] = slices.Index(knnc.TrainingData.ClassNames, className); classes[i] == -1 {
+			return fmt.Errorf("Class %s is not in the training data", className)
+		}
+	}
+
+	records := make([]Record, len(ds.Records))
+	for i, r := range ds.Records {
+		if r.Class < 0 || r.Class >= len(classes) {
+			return fmt.Errorf("Invalid record at index %d: unknown class %d", i, r.Class)
+		}
+		records[i] = Record{Class: classes[r.Class], AttributeValues: r.AttributeValues}
+	}
+
+	return knnc.addRecords(records)
+}
+
+// Test classifies the records of the testing data
 func (knnc *KNearestNeighborClassifier) Test() (TestResults, error) {
+	return knnc.TestContext(context.Background())
+}
+
+// TestContext classifies the records of the testing data, stopping early if the context
+// is cancelled
+func (knnc *KNearestNeighborClassifier) TestContext(ctx context.Context) (TestResults, error) {
+	return knnc.test(ctx, knnc.classifyRecord)
+}
+
+// Predict classifies a single unlabeled record, represented by its attribute values.
+// The values must be in the same order as the AttributeNames of the training data.
+func (knnc *KNearestNeighborClassifier) Predict(values wyvern.Vector[float64]) (Prediction, error) {
+	knnc.lock.RLock()
+	defer knnc.lock.RUnlock()
+
+	if err := knnc.checkValues(values); err != nil {
+		return Prediction{}, err
+	}
+
+	return knnc.prediction(knnc.classifyRecord(Record{Class: NO_PREDICTION, AttributeValues: values}, 0)), nil
+}
+
+// PredictBatch classifies each of the unlabeled records in the batch.  If any member
+// of the batch is invalid, no predictions are made.
+func (knnc *KNearestNeighborClassifier) PredictBatch(batch []wyvern.Vector[float64]) ([]Prediction, error) {
+	knnc.lock.RLock()
+	defer knnc.lock.RUnlock()
+
+	if err := knnc.checkBatch(batch); err != nil {
+		return nil, err
+	}
+
+	predictions := make([]Prediction, len(batch))
+	for i, values := range batch {
+		predictions[i] = knnc.prediction(knnc.classifyRecord(Record{Class: NO_PREDICTION, AttributeValues: values}, i))
+	}
+
+	return predictions, nil
+}
+
+func (knnc *KNearestNeighborClassifier) classifyRecord(r Record, seq int) TestResult {
+	return knnc.classify(r, knnc.nearest(r.AttributeValues, knnc.Configuration.K), seq)
+}
+
+// TestExplained tests the model like TestContext, but each result also lists the neighbors
+// which voted on its prediction.
+func (knnc *KNearestNeighborClassifier) TestExplained(ctx context.Context) (TestResults, error) {
+	return knnc.test(ctx, knnc.explainRecord)
+}
+
+// test classifies the records of the testing data with classify, and keeps the results
+func (knnc *KNearestNeighborClassifier) test(ctx context.Context, classify func(Record, int) TestResult) (TestResults, error) {
+	knnc.lock.RLock()
 	if knnc.TrainingData == nil || knnc.TestingData == nil {
+		knnc.lock.RUnlock()
 		return nil, errors.New("Untestable model")
 	}
-	results := make(TestResults, len(knnc.TestingData.Records))
-	for i, testRecord := range knnc.TestingData.Records {
-		results[i] = classify(testRecord,
-			computeNeighbors(testRecord, knnc.TrainingData.Records, knnc.Configuration.distanceFunction),
-			knnc.Configuration.K,
-			len(knnc.RawData.ClassNames))
+
+	results, err := classifyAll(ctx, knnc.TestingData.Records, knnc.Configuration.Concurrency, classify)
+	knnc.lock.RUnlock()
+	if err != nil {
+		return nil, err
 	}
 
+	knnc.lock.Lock()
 	knnc.Results = results
+	knnc.lock.Unlock()
 	return results, nil
 }
 
+func (knnc *KNearestNeighborClassifier) explainRecord(r Record, seq int) TestResult {
+	result, voters := knnc.classifyVoters(r, knnc.nearest(r.AttributeValues, knnc.Configuration.K), seq)
+
+	result.Neighbors = make([]ExplainedNeighbor, len(voters))
+	for i, voter := range voters {
+		result.Neighbors[i] = ExplainedNeighbor{
+			Index:           voter.Index,
+			ClassName:       knnc.TrainingData.ClassNames[voter.Class],
+			Distance:        voter.Distance,
+			AttributeValues: knnc.TrainingData.Records[voter.Index].AttributeValues,
+		}
+	}
+
+	return result
+}
+
+// IndexRecallReport compares the neighbors found by the configured index with the exact
+// (brute force) neighbors of the test records
+type IndexRecallReport struct {
+	Index   string `json:"index"`
+	K       int    `json:"k"`
+	Queries int    `json:"queries"`
+	// Recall is the mean share of each record's exact K nearest neighbors found by the index.  A
+	// neighbor found by the index counts if it is no farther than the exact Kth nearest neighbor,
+	// so that neighbors tied at that distance are interchangeable.
+	Recall float64 `json:"recall"`
+	// MinRecall is the recall of the worst served test record
+	MinRecall float64 `json:"min_recall"`
+	// PredictionAgreement is the share of test records for which the index and the exact
+	// search lead to the same prediction
+	PredictionAgreement float64 `json:"prediction_agreement"`
+}
+
+// IndexRecall measures the recall of the configured neighbor index against an exact search
+// over the test split.  It is mainly useful for tuning approximate (IndexMethod_HNSW) indexes.
+func (knnc *KNearestNeighborClassifier) IndexRecall() (IndexRecallReport, error) {
+	knnc.lock.RLock()
+	defer knnc.lock.RUnlock()
+
+	if knnc.TrainingData == nil || knnc.TestingData == nil || len(knnc.TestingData.Records) == 0 {
+		return IndexRecallReport{}, errors.New("Model has no test data")
+	}
+
+	// With no training records there are no neighbors to find
+	if len(knnc.TrainingData.Records) == 0 {
+		return IndexRecallReport{}, errors.New("Model has no training data")
+	}
+
+	report := IndexRecallReport{
+		Index:     knnc.Configuration.Index,
+		K:         knnc.Configuration.K,
+		Queries:   len(knnc.TestingData.Records),
+		MinRecall: 1,
+	}
+
+	exactIndex := &bruteForceIndex{indexedPoints: knnc.indexedPoints()}
+	var agreements int
+	for seq, r := range knnc.TestingData.Records {
+		exact := exactIndex.nearest(knnc.scaled(r.AttributeValues), knnc.Configuration.K)
+		approximate := knnc.nearest(r.AttributeValues, knnc.Configuration.K)
+
+		var found int
+		bound := exact[len(exact)-1].Distance
+		for _, n := range approximate {
+			if n.Distance <= bound {
+				found++
+			}
+		}
+
+		recall := float64(found) / float64(len(exact))
+		report.Recall += recall
+		report.MinRecall = math.Min(report.MinRecall, recall)
+
+		if knnc.classify(r, exact, seq).Predicted == knnc.classify(r, approximate, seq).Predicted {
+			agreements++
+		}
+	}
+
+	report.Recall /= float64(report.Queries)
+	report.PredictionAgreement = float64(agreements) / float64(report.Queries)
+	return report, nil
+}
+
+// nearest returns the k training records nearest to the values, nearest first
+func (knnc *KNearestNeighborClassifier) nearest(values wyvern.Vector[float64], k int) []Neighbor {
+	return knnc.index.nearest(knnc.scaled(values), k)
+}
+
 type Neighbor struct {
+	// Index is the position of the neighbor in the training data
+	Index    int
 	Class    int
 	Distance float64
 }
 
-// classify assumes that neighbors has been sorted by distance already
-func classify(orig Record, neighbors []Neighbor, k, classCount int) TestResult {
+// ExplainedNeighbor is a neighbor which voted on the prediction of a test record
+type ExplainedNeighbor struct {
+	// Index is the position of the neighbor in TrainingData.Records
+	Index     int
+	ClassName string
+	// Distance is measured after any attribute weights have been applied
+	Distance        float64
+	AttributeValues wyvern.Vector[float64]
+}
+
+// classify assumes that neighbors has been sorted by distance already.  seq is the position
+// of the record in the set being classified, and is used to seed random tie-breaking.
+func (knnc *KNearestNeighborClassifier) classify(orig Record, neighbors []Neighbor, seq int) TestResult {
+	result, _ := knnc.classifyVoters(orig, neighbors, seq)
+	return result
+}
+
+// classifyVoters is classify, but also returns the neighbors which voted, of which there may be
+// more than K if ties are broken by expanding K.
+func (knnc *KNearestNeighborClassifier) classifyVoters(orig Record, neighbors []Neighbor, seq int) (TestResult, []Neighbor) {
+	classNames := knnc.TrainingData.ClassNames
 	result := TestResult{
-		Record: orig,
+		Record:        orig,
+		Predicted:     NO_PREDICTION,
+		Probabilities: make(map[string]float64, len(classNames)),
+	}
+
+	k := min(knnc.Configuration.K, len(neighbors))
+	probabilities, leaders := knnc.vote(neighbors[:k])
+	result.Tied = len(leaders) > 1
+
+	if result.Tied && knnc.Configuration.TieBreak == TieBreak_ExpandK {
+		for len(leaders) > 1 {
+			// Fetch more neighbors than needed, so that we do not search on every step.  An
+			// approximate index may find fewer neighbors than there are training records.
+			if k == len(neighbors) {
+				more := knnc.nearest(orig.AttributeValues, 2*(k+1))
+				if len(more) <= k {
+					break
+				}
+				neighbors = more
+			}
+			k++
+			probabilities, leaders = knnc.vote(neighbors[:k])
+		}
+	}
+
+	for i, className := range classNames {
+		result.Probabilities[className] = probabilities[i]
 	}
 
-	votingNeighbors := neighbors[:k]
-	votes := make([]int, k)
+	if result.Predicted = knnc.breakTie(leaders, neighbors[:k], seq); result.Predicted == NO_PREDICTION {
+		return result, neighbors[:k]
+	}
+
+	result.Probability = probabilities[result.Predicted]
+	for _, neighbor := range neighbors[:k] {
+		if neighbor.Class == result.Predicted {
+			result.Votes++
+		}
+	}
+
+	return result, neighbors[:k]
+}
+
+// vote computes the probability of each class from the votes of the neighbors, and returns
+// the probabilities along with the (ascending) indices of the class(es) with the highest probability.
+func (knnc *KNearestNeighborClassifier) vote(votingNeighbors []Neighbor) ([]float64, []int) {
+	classCount := len(knnc.TrainingData.ClassNames)
+	votes := make([]int, len(votingNeighbors))
 
 	// Collect the votes
 	for ni, neighbor := range votingNeighbors {
@@ -136,39 +726,142 @@ func classify(orig Record, neighbors []Neighbor, k, classCount int) TestResult {
 	}
 
 	// Create the probability mass function
-	pmf := probability.MassDiscrete(votes)
+	var pmf func(int) float64
+	if weights := knnc.Configuration.neighborWeights(votingNeighbors); weights == nil {
+		pmf = probability.MassDiscrete(votes)
+	} else {
+		pmf = weightedMass(votes, weights, classCount)
+	}
 
-	predicted := NO_PREDICTION
-	predictedProbability := 0.0
+	probabilities := make([]float64, classCount)
+	highest := 0.0
+	for i := range probabilities {
+		probabilities[i] = pmf(i)
+		highest = math.Max(highest, probabilities[i])
+	}
 
-	// Determine the class
-	for i := 0; i < classCount; i++ {
-		probability := pmf(i)
-		if probability > predictedProbability {
-			predicted = i
-			predictedProbability = probability
+	// Weighted probabilities are sums of floats, so allow for rounding when comparing them
+	leaders := make([]int, 0, 1)
+	for i, p := range probabilities {
+		if p > 0 && highest-p <= highest*1e-12 {
+			leaders = append(leaders, i)
 		}
 	}
 
-	result.Predicted = predicted
-	result.Probability = predictedProbability
+	return probabilities, leaders
+}
 
-	return result
+// breakTie chooses the predicted class from among the leading classes according to the
+// configured tie-break policy.
+func (knnc *KNearestNeighborClassifier) breakTie(leaders []int, votingNeighbors []Neighbor, seq int) int {
+	if len(leaders) == 0 {
+		return NO_PREDICTION
+	}
+
+	if len(leaders) == 1 {
+		return leaders[0]
+	}
+
+	switch knnc.Configuration.TieBreak {
+	case TieBreak_Nearest, TieBreak_ExpandK:
+		// Expanding K may run out of neighbors before the tie is broken, in which case
+		// the nearest neighbor decides
+		for _, neighbor := range votingNeighbors {
+			if slices.Contains(leaders, neighbor.Class) {
+				return neighbor.Class
+			}
+		}
+	case TieBreak_LowestTotalDistance:
+		totals := make(map[int]float64, len(leaders))
+		for _, neighbor := range votingNeighbors {
+			totals[neighbor.Class] += neighbor.Distance
+		}
+
+		predicted := leaders[0]
+		for _, class := range leaders[1:] {
+			if totals[class] < totals[predicted] {
+				predicted = class
+			}
+		}
+
+		return predicted
+	case TieBreak_Random:
+		return leaders[rand.New(rand.NewSource(knnc.Configuration.Seed+int64(seq))).Intn(len(leaders))]
+	case TieBreak_None:
+		return NO_PREDICTION
+	}
+
+	return leaders[0]
 }
 
-// Take an individual record, order the records from ds by proximity, return the ordered list
-func computeNeighbors(orig Record, comps []Record, distanceFunction DistanceFunction) []Neighbor {
-	neighbors := make([]Neighbor, len(comps))
-	for i, r := range comps {
-		neighbors[i] = Neighbor{
-			Class:    r.Class,
-			Distance: distanceFunction(orig.AttributeValues, r.AttributeValues),
+// neighborWeights returns the weight of each neighbor's vote, or nil if every vote counts equally
+func (cfg KNearestNeighborClassifierConfig) neighborWeights(neighbors []Neighbor) []float64 {
+	if cfg.Weighting == Weighting_Uniform || len(neighbors) == 0 {
+		return nil
+	}
+
+	weights := make([]float64, len(neighbors))
+
+	switch cfg.Weighting {
+	case Weighting_InverseDistance, Weighting_InverseSquaredDistance:
+		// Exact matches get all of the weight (the inverse of 0 being infinite)
+		if neighbors[0].Distance == 0 {
+			for ni, neighbor := range neighbors {
+				if neighbor.Distance == 0 {
+					weights[ni] = 1
+				}
+			}
+
+			return weights
+		}
+
+		for ni, neighbor := range neighbors {
+			if cfg.Weighting == Weighting_InverseDistance {
+				weights[ni] = 1 / neighbor.Distance
+			} else {
+				weights[ni] = 1 / (neighbor.Distance * neighbor.Distance)
+			}
+		}
+	case Weighting_Gaussian:
+		bandwidth := cfg.Bandwidth
+		if bandwidth == 0 {
+			bandwidth = neighbors[len(neighbors)-1].Distance
+		}
+
+		// All of the neighbors are exact matches
+		if bandwidth == 0 {
+			return nil
+		}
+
+		for ni, neighbor := range neighbors {
+			weights[ni] = math.Exp(-(neighbor.Distance * neighbor.Distance) / (2 * bandwidth * bandwidth))
+		}
+	}
+
+	// If the weights have all underflowed, fall back to counting the votes
+	for _, w := range weights {
+		if w > 0 {
+			return weights
 		}
 	}
 
-	sort.Slice(neighbors, func(i, j int) bool {
-		return neighbors[i].Distance < neighbors[j].Distance
-	})
+	return nil
+}
 
-	return neighbors
+// weightedMass returns a probability mass function over the class indices, where each vote
+// counts for its weight rather than for one
+func weightedMass(votes []int, weights []float64, classCount int) func(int) float64 {
+	var total float64
+	totals := make([]float64, classCount)
+	for vi, class := range votes {
+		totals[class] += weights[vi]
+		total += weights[vi]
+	}
+
+	return func(class int) float64 {
+		if class < 0 || class >= classCount {
+			return 0
+		}
+		return totals[class] / total
+	}
 }
@@ -0,0 +1,145 @@
+package classifiers
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// The share of the records classified correctly
+	AutoKMetric_Accuracy = "accuracy"
+	// The mean of the share of each class's records classified correctly, which is not
+	// inflated by favoring the most common classes
+	AutoKMetric_BalancedAccuracy = "balanced_accuracy"
+
+	DEFAULT_AUTO_K_MIN   = 1
+	DEFAULT_AUTO_K_MAX   = 25
+	DEFAULT_AUTO_K_FOLDS = 5
+)
+
+// AutoKConfig controls the selection of K by k-fold cross-validation on the training data.
+// Every K from MinK to MaxK is scored, and the K with the best mean score over the folds is
+// used (the smallest such K, if several score the same).
+type AutoKConfig struct {
+	// MinK is the smallest K tried - 0 means DEFAULT_AUTO_K_MIN
+	MinK int
+	// MaxK is the largest K tried - 0 means DEFAULT_AUTO_K_MAX
+	MaxK int
+	// Folds is the number of cross-validation folds - 0 means DEFAULT_AUTO_K_FOLDS
+	Folds int
+	// Metric is one of the AutoKMetric_ values, empty means AutoKMetric_Accuracy
+	Metric string
+	// Scores holds the cross-validation score of every K tried, filled in when the model is trained
+	Scores []AutoKScore
+}
+
+// AutoKScore is the cross-validation score of a single K
+type AutoKScore struct {
+	K int
+	// Mean is the mean of the scores of the folds
+	Mean float64
+	// StdDev is the (population) standard deviation of the scores of the folds
+	StdDev float64
+}
+
+func (cfg *AutoKConfig) validate() error {
+	if cfg.MinK == 0 {
+		cfg.MinK = DEFAULT_AUTO_K_MIN
+	}
+
+	if cfg.MaxK == 0 {
+		cfg.MaxK = max(DEFAULT_AUTO_K_MAX, cfg.MinK)
+	}
+
+	if cfg.Folds == 0 {
+		cfg.Folds = DEFAULT_AUTO_K_FOLDS
+	}
+
+	if cfg.Metric == "" {
+		cfg.Metric = AutoKMetric_Accuracy
+	}
+
+	switch {
+	case cfg.MinK < 1:
+		return fmt.Errorf("the smallest K tried must be at least 1, not %d", cfg.MinK)
+	case cfg.MaxK < cfg.MinK:
+		return fmt.Errorf("the largest K tried (%d) cannot be less than the smallest (%d)", cfg.MaxK, cfg.MinK)
+	case cfg.Folds < 2:
+		return fmt.Errorf("at least 2 cross-validation folds are needed, not %d", cfg.Folds)
+	case cfg.Metric != AutoKMetric_Accuracy && cfg.Metric != AutoKMetric_BalancedAccuracy:
+		return fmt.Errorf("unknown auto K metric %s", cfg.Metric)
+	}
+
+	// Scores from a previous training are not carried over
+	cfg.Scores = nil
+	return nil
+}
+
+// selectK scores each candidate K by cross-validation on the TrainingData, recording the
+// scores in the AutoK configuration and setting K to the best candidate
+func (knnc *KNearestNeighborClassifier) selectK() error {
+	autoK := knnc.Configuration.AutoK
+	trainingSets, testSets, err := knnc.TrainingData.KFold(autoK.Folds)
+	if err != nil {
+		return err
+	}
+
+	// foldScores[i][f] is the score of K = MinK + i on fold f
+	foldScores := make([][]float64, autoK.MaxK-autoK.MinK+1)
+	for i := range foldScores {
+		foldScores[i] = make([]float64, autoK.Folds)
+	}
+
+	for f := range trainingSets {
+		fold := &KNearestNeighborClassifier{Configuration: knnc.Configuration}
+		fold.Configuration.AutoK = nil
+		fold.TrainingData, fold.TestingData = trainingSets[f], testSets[f]
+		if err = fold.fit(); err != nil {
+			return err
+		}
+
+		// Search once for the most neighbors needed, then vote with the first K of them
+		neighbors := make([][]Neighbor, len(fold.TestingData.Records))
+		for i, r := range fold.TestingData.Records {
+			neighbors[i] = fold.nearest(r.AttributeValues, autoK.MaxK)
+		}
+
+		results := make(TestResults, len(fold.TestingData.Records))
+		for ki := range foldScores {
+			fold.Configuration.K = autoK.MinK + ki
+			for i, r := range fold.TestingData.Records {
+				results[i] = fold.classify(r, neighbors[i], i)
+			}
+			foldScores[ki][f] = autoKScore(autoK.Metric, results, len(knnc.TrainingData.ClassNames))
+		}
+	}
+
+	autoK.Scores = make([]AutoKScore, len(foldScores))
+	best := 0
+	for ki, scores := range foldScores {
+		var mean, variance float64
+		for _, score := range scores {
+			mean += score / float64(len(scores))
+		}
+		for _, score := range scores {
+			variance += (score - mean) * (score - mean) / float64(len(scores))
+		}
+
+		autoK.Scores[ki] = AutoKScore{K: autoK.MinK + ki, Mean: mean, StdDev: math.Sqrt(variance)}
+		if mean > autoK.Scores[best].Mean {
+			best = ki
+		}
+	}
+
+	knnc.Configuration.K = autoK.Scores[best].K
+	return nil
+}
+
+// autoKScore scores the results of one fold using the metric
+func autoKScore(metric string, results TestResults, classCount int) float64 {
+	if metric == AutoKMetric_BalancedAccuracy {
+		return results.BalancedAccuracy(classCount)
+	}
+
+	return results.Analyze().Accuracy
+}
@@ -0,0 +1,84 @@
+package classifiers_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/ScarletTanager/basilisk/classifiers"
+	"github.com/ScarletTanager/wyvern"
+)
+
+// benchmarkDataSet generates a dataset of uniformly distributed records, with the class
+// determined by the first attribute.
+func benchmarkDataSet(recordCount, attributeCount int) *classifiers.DataSet {
+	rng := rand.New(rand.NewSource(1))
+	attributes := make([]string, attributeCount)
+	for i := range attributes {
+		attributes[i] = string(rune('a' + i))
+	}
+
+	records := make([]classifiers.Record, recordCount)
+	for i := range records {
+		values := make(wyvern.Vector[float64], attributeCount)
+		for a := range values {
+			values[a] = rng.Float64() * 100
+		}
+		records[i] = classifiers.Record{Class: int(values[0]) / 34, AttributeValues: values}
+	}
+
+	ds, _ := classifiers.NewDataSet([]string{"low", "middle", "high"}, attributes, records)
+	return ds
+}
+
+func benchmarkKnnTest(b *testing.B, ds *classifiers.DataSet, cfg classifiers.KNearestNeighborClassifierConfig) {
+	knnc, err := classifiers.NewKnnFromConfig(cfg)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	if err = knnc.TrainFromDataset(ds, &classifiers.DataSplitConfig{Method: classifiers.SplitSequential, TrainingShare: .9}); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err = knnc.Test(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkKnnIndex(b *testing.B) {
+	ds := benchmarkDataSet(5000, 4)
+
+	for _, index := range []string{classifiers.IndexMethod_BruteForce, classifiers.IndexMethod_KDTree, classifiers.IndexMethod_BallTree, classifiers.IndexMethod_HNSW} {
+		b.Run(index, func(b *testing.B) {
+			benchmarkKnnTest(b, ds, classifiers.KNearestNeighborClassifierConfig{K: 5, Index: index})
+		})
+	}
+}
+
+func BenchmarkKnnBruteForce(b *testing.B) {
+	datasets := []struct {
+		name string
+		load func() (*classifiers.DataSet, error)
+	}{
+		{"iris", func() (*classifiers.DataSet, error) { return classifiers.FromCSVFile("../datasets/iris.csv") }},
+		{"shorebirds", func() (*classifiers.DataSet, error) { return classifiers.FromJSONFile("../datasets/shorebirds.json") }},
+	}
+
+	for _, d := range datasets {
+		ds, err := d.load()
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		for _, k := range []int{1, 5, 15} {
+			b.Run(fmt.Sprintf("%s/k=%d", d.name, k), func(b *testing.B) {
+				b.ReportAllocs()
+				benchmarkKnnTest(b, ds, classifiers.KNearestNeighborClassifierConfig{K: k})
+			})
+		}
+	}
+}
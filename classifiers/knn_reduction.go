@@ -0,0 +1,183 @@
+package classifiers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+const (
+	// Hart's condensed nearest neighbor: keep only the records needed to classify the rest
+	// of the training data correctly with their single nearest kept neighbor
+	Reduction_Condensed = "condensed"
+	// Wilson's edited nearest neighbor: drop the records misclassified by their EditK
+	// nearest neighbors, which removes noise and smooths the class boundaries
+	Reduction_Edited = "edited"
+	// Edit the training data, then condense what remains
+	Reduction_EditedCondensed = "edited_condensed"
+
+	DEFAULT_REDUCTION_EDIT_K = 3
+)
+
+// ReductionConfig controls the reduction of the training data after a model is trained, so
+// that fewer records need to be stored and searched.  The counts and accuracies are filled in
+// when the model is trained.  The accuracies are measured on the testing data, and are left
+// at zero if there is none.
+type ReductionConfig struct {
+	// Method is one of the Reduction_ values
+	Method string
+	// EditK is the number of neighbors which classify each record when editing - 0 means
+	// DEFAULT_REDUCTION_EDIT_K
+	EditK int
+	// OriginalCount is the number of training records before reduction
+	OriginalCount int
+	// KeptCount is the number of training records kept
+	KeptCount int
+	// AccuracyBefore is the accuracy of the model before reduction
+	AccuracyBefore float64
+	// AccuracyAfter is the accuracy of the model after reduction
+	AccuracyAfter float64
+}
+
+func (cfg *ReductionConfig) validate() error {
+	if cfg.EditK == 0 {
+		cfg.EditK = DEFAULT_REDUCTION_EDIT_K
+	}
+
+	switch {
+	case cfg.Method != Reduction_Condensed && cfg.Method != Reduction_Edited && cfg.Method != Reduction_EditedCondensed:
+		return fmt.Errorf("unknown reduction method %s", cfg.Method)
+	case cfg.EditK < 1:
+		return fmt.Errorf("editing needs at least 1 neighbor, not %d", cfg.EditK)
+	}
+
+	// Results from a previous training are not carried over
+	cfg.OriginalCount, cfg.KeptCount = 0, 0
+	cfg.AccuracyBefore, cfg.AccuracyAfter = 0, 0
+	return nil
+}
+
+// reduce replaces the TrainingData of a fitted model with the records kept by the configured
+// reduction method, and rebuilds the index.  The attribute weights and the covariance used by
+// mahalanobis distance are those of the full training data.  Fails if editing keeps none of
+// the records.
+func (knnc *KNearestNeighborClassifier) reduce() error {
+	reduction := knnc.Configuration.Reduction
+	reduction.OriginalCount = len(knnc.TrainingData.Records)
+
+	var err error
+	if reduction.AccuracyBefore, err = knnc.testAccuracy(); err != nil {
+		return err
+	}
+
+	kept := allIndices(len(knnc.TrainingData.Records))
+	if reduction.Method == Reduction_Edited || reduction.Method == Reduction_EditedCondensed {
+		kept = knnc.edited(reduction.EditK)
+		if len(kept) == 0 {
+			return errors.New("editing removed every training record")
+		}
+	}
+
+	if reduction.Method == Reduction_Condensed || reduction.Method == Reduction_EditedCondensed {
+		kept = knnc.condensed(kept)
+	}
+
+	records := make([]Record, len(kept))
+	for i, r := range kept {
+		records[i] = knnc.TrainingData.Records[r]
+	}
+
+	knnc.TrainingData, _ = NewDataSet(knnc.TrainingData.ClassNames, knnc.TrainingData.AttributeNames, records)
+	knnc.buildIndex(knnc.indexedPoints())
+	reduction.KeptCount = len(records)
+
+	reduction.AccuracyAfter, err = knnc.testAccuracy()
+	return err
+}
+
+// edited returns the indices of the training records which are classified correctly by
+// their k nearest neighbors (not counting themselves)
+func (knnc *KNearestNeighborClassifier) edited(k int) []int {
+	kept := make([]int, 0, len(knnc.TrainingData.Records))
+	for i, r := range knnc.TrainingData.Records {
+		neighbors := make([]Neighbor, 0, k)
+		for _, neighbor := range knnc.nearest(r.AttributeValues, k+1) {
+			if neighbor.Index != i && len(neighbors) < k {
+				neighbors = append(neighbors, neighbor)
+			}
+		}
+
+		_, leaders := knnc.vote(neighbors)
+		if knnc.breakTie(leaders, neighbors, i) == r.Class {
+			kept = append(kept, i)
+		}
+	}
+
+	return kept
+}
+
+// condensed returns the subset of the candidates (indices of training records) needed to
+// classify every candidate correctly by its nearest neighbor in the subset.  The subset
+// starts with the first candidate, and any candidate which it misclassifies is added, until
+// a full pass over the candidates adds nothing.
+func (knnc *KNearestNeighborClassifier) condensed(candidates []int) []int {
+	if len(candidates) == 0 {
+		return candidates
+	}
+
+	ip := knnc.indexedPoints()
+	store := &bruteForceIndex{indexedPoints: indexedPoints{distance: ip.distance}}
+	stored := make([]bool, len(ip.points))
+
+	add := func(i int) {
+		store.insert(ip.points[i], ip.classes[i])
+		stored[i] = true
+	}
+
+	add(candidates[0])
+	for added := true; added; {
+		added = false
+		for _, i := range candidates {
+			if stored[i] {
+				continue
+			}
+
+			if nearest := store.nearest(ip.points[i], 1); nearest[0].Class != ip.classes[i] {
+				add(i)
+				added = true
+			}
+		}
+	}
+
+	// Keep the records in their original order
+	kept := make([]int, 0, len(store.points))
+	for _, i := range candidates {
+		if stored[i] {
+			kept = append(kept, i)
+		}
+	}
+
+	return kept
+}
+
+// testAccuracy returns the share of the testing records which the model classifies
+// correctly, or 0 if there are none
+func (knnc *KNearestNeighborClassifier) testAccuracy() (float64, error) {
+	if knnc.TestingData == nil || len(knnc.TestingData.Records) == 0 {
+		return 0, nil
+	}
+
+	results, err := classifyAll(context.Background(), knnc.TestingData.Records, knnc.Configuration.Concurrency, knnc.classifyRecord)
+	if err != nil {
+		return 0, err
+	}
+
+	correct := 0
+	for _, result := range results {
+		if result.Predicted == result.Class {
+			correct++
+		}
+	}
+
+	return float64(correct) / float64(len(results)), nil
+}
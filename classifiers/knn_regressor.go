@@ -0,0 +1,261 @@
+package classifiers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ScarletTanager/wyvern"
+)
+
+const (
+	RegressorType_KNearestNeighbor string = "KNearestNeighbors Regressor"
+)
+
+// KNearestNeighborRegressorConfig holds the options of a KNN regressor, which have the same
+// meaning (and defaults) as those of KNearestNeighborClassifierConfig.  Weighting weights the
+// average of the neighbors' targets rather than their votes.
+type KNearestNeighborRegressorConfig struct {
+	K                int
+	DistanceMethod   string
+	MinkowskiP       float64
+	Weighting        string
+	Bandwidth        float64
+	Index            string
+	HNSW             HNSWConfig
+	Seed             int64
+	Concurrency      int
+	AttributeWeights map[string]float64
+}
+
+// KNearestNeighborRegressor predicts a continuous target as the (optionally distance
+// weighted) mean of the targets of the K nearest training records
+type KNearestNeighborRegressor struct {
+	RawData       *RegressionDataSet
+	TrainingData  *RegressionDataSet
+	TestingData   *RegressionDataSet
+	Results       RegressionResults
+	Configuration KNearestNeighborRegressorConfig
+	// search finds the nearest training records.  It is a classifier trained on the same
+	// attribute values, with every record in a single class.
+	search *KNearestNeighborClassifier
+}
+
+// RegressionResults holds the predictions for the records of a RegressionDataSet
+type RegressionResults []RegressionResult
+
+type RegressionResult struct {
+	RegressionRecord
+	Predicted float64 `json:"predicted"`
+}
+
+// RegressionAnalysis summarizes the errors of a set of predictions
+type RegressionAnalysis struct {
+	ResultCount int `json:"results"`
+	// MeanAbsoluteError is the mean of the absolute differences between the predicted and actual targets
+	MeanAbsoluteError float64 `json:"mae"`
+	// RootMeanSquaredError is the square root of the mean of the squared differences
+	RootMeanSquaredError float64 `json:"rmse"`
+	// RSquared is the coefficient of determination: the share of the variance of the actual
+	// targets explained by the predictions.  It is 1 for perfect predictions, 0 for always
+	// predicting the mean target, and negative for worse.  If every target is the same, it is
+	// 1 if the predictions are perfect and 0 otherwise.
+	RSquared float64 `json:"r2"`
+}
+
+func (rrs RegressionResults) Analyze() RegressionAnalysis {
+	analysis := RegressionAnalysis{
+		ResultCount: len(rrs),
+	}
+
+	if len(rrs) == 0 {
+		return analysis
+	}
+
+	var mean, absolute, squared, total float64
+	for _, result := range rrs {
+		mean += result.Target
+	}
+	mean /= float64(len(rrs))
+
+	for _, result := range rrs {
+		diff := result.Predicted - result.Target
+		absolute += math.Abs(diff)
+		squared += diff * diff
+		total += (result.Target - mean) * (result.Target - mean)
+	}
+
+	analysis.MeanAbsoluteError = absolute / float64(len(rrs))
+	analysis.RootMeanSquaredError = math.Sqrt(squared / float64(len(rrs)))
+
+	switch {
+	case total > 0:
+		analysis.RSquared = 1 - squared/total
+	case squared == 0:
+		analysis.RSquared = 1
+	}
+
+	return analysis
+}
+
+func NewKnnRegressor(k int, distanceMethod string) (*KNearestNeighborRegressor, error) {
+	return NewKnnRegressorFromConfig(KNearestNeighborRegressorConfig{K: k, DistanceMethod: distanceMethod})
+}
+
+// NewKnnRegressorFromConfig creates a regressor with the full set of KNN options
+func NewKnnRegressorFromConfig(cfg KNearestNeighborRegressorConfig) (*KNearestNeighborRegressor, error) {
+	search, err := NewKnnFromConfig(KNearestNeighborClassifierConfig{
+		K:                cfg.K,
+		DistanceMethod:   cfg.DistanceMethod,
+		MinkowskiP:       cfg.MinkowskiP,
+		Weighting:        cfg.Weighting,
+		Bandwidth:        cfg.Bandwidth,
+		Seed:             cfg.Seed,
+		Index:            cfg.Index,
+		HNSW:             cfg.HNSW,
+		Concurrency:      cfg.Concurrency,
+		AttributeWeights: cfg.AttributeWeights,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Unable to create regressor: %w", err)
+	}
+
+	// Pick up the defaults
+	searchCfg := search.Configuration
+	cfg.DistanceMethod, cfg.MinkowskiP = searchCfg.DistanceMethod, searchCfg.MinkowskiP
+	cfg.Weighting, cfg.Index, cfg.HNSW = searchCfg.Weighting, searchCfg.Index, searchCfg.HNSW
+	cfg.Concurrency = searchCfg.Concurrency
+
+	return &KNearestNeighborRegressor{
+		Configuration: cfg,
+		search:        search,
+	}, nil
+}
+
+func (knnr *KNearestNeighborRegressor) Type() string {
+	return RegressorType_KNearestNeighbor
+}
+
+func (knnr *KNearestNeighborRegressor) Config() interface{} {
+	return knnr.Configuration
+}
+
+func (knnr *KNearestNeighborRegressor) TrainFromCSVFile(path string, cfg *DataSplitConfig) error {
+	ds, err := RegressionFromCSVFile(path)
+	if err != nil {
+		return fmt.Errorf("Error training from CSV file %s: %w", path, err)
+	}
+
+	return knnr.train(ds, cfg)
+}
+
+func (knnr *KNearestNeighborRegressor) TrainFromJSONFile(path string, cfg *DataSplitConfig) error {
+	ds, err := RegressionFromJSONFile(path)
+	if err != nil {
+		return fmt.Errorf("Error training from JSON file: %w", err)
+	}
+
+	return knnr.train(ds, cfg)
+}
+
+func (knnr *KNearestNeighborRegressor) TrainFromDataset(ds *RegressionDataSet, cfg *DataSplitConfig) error {
+	return knnr.train(ds, cfg)
+}
+
+func (knnr *KNearestNeighborRegressor) Retrain(cfg *DataSplitConfig) error {
+	return knnr.train(knnr.RawData, cfg)
+}
+
+// train splits the data and fits a new search to the training data, replacing the regressor's
+// data and search only if training succeeds
+func (knnr *KNearestNeighborRegressor) train(ds *RegressionDataSet, cfg *DataSplitConfig) error {
+	if ds == nil {
+		return errors.New("Unable to train model, no data")
+	}
+
+	training, testing, err := ds.Split(cfg)
+	if err != nil {
+		return err
+	}
+
+	if len(training.Records) == 0 {
+		return errors.New("Unable to train model, no training records")
+	}
+
+	records := make([]Record, len(training.Records))
+	for i, r := range training.Records {
+		records[i] = Record{AttributeValues: r.AttributeValues}
+	}
+
+	search := &KNearestNeighborClassifier{Configuration: knnr.search.Configuration.clone()}
+	if search.TrainingData, err = NewDataSet([]string{training.TargetName}, training.AttributeNames, records); err != nil {
+		return fmt.Errorf("Unable to train regressor: %w", err)
+	}
+
+	if err = search.fit(); err != nil {
+		return err
+	}
+
+	knnr.RawData, knnr.TrainingData, knnr.TestingData = ds, training, testing
+	knnr.Results = nil
+	knnr.search = search
+	return nil
+}
+
+// Test predicts the targets of the records of the testing data
+func (knnr *KNearestNeighborRegressor) Test() (RegressionResults, error) {
+	return knnr.TestContext(context.Background())
+}
+
+// TestContext predicts the targets of the records of the testing data, stopping early if the
+// context is cancelled
+func (knnr *KNearestNeighborRegressor) TestContext(ctx context.Context) (RegressionResults, error) {
+	if knnr.TrainingData == nil || knnr.TestingData == nil || len(knnr.TrainingData.Records) == 0 {
+		return nil, errors.New("Untestable model")
+	}
+
+	results, err := classifyAll(ctx, knnr.TestingData.Records, knnr.Configuration.Concurrency, func(r RegressionRecord, _ int) RegressionResult {
+		return RegressionResult{RegressionRecord: r, Predicted: knnr.predict(r.AttributeValues)}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	knnr.Results = results
+	return results, nil
+}
+
+// Predict predicts the target of a single unlabeled record, represented by its attribute
+// values.  The values must be in the same order as the AttributeNames of the training data.
+func (knnr *KNearestNeighborRegressor) Predict(values wyvern.Vector[float64]) (float64, error) {
+	if knnr.TrainingData == nil || len(knnr.TrainingData.Records) == 0 {
+		return 0, errors.New("Model has not been trained")
+	}
+
+	if len(values) != len(knnr.TrainingData.AttributeNames) {
+		return 0, fmt.Errorf("Expected %d attribute values %v, found %d",
+			len(knnr.TrainingData.AttributeNames), knnr.TrainingData.AttributeNames, len(values))
+	}
+
+	return knnr.predict(values), nil
+}
+
+// predict averages the targets of the K nearest neighbors, weighted as configured
+func (knnr *KNearestNeighborRegressor) predict(values wyvern.Vector[float64]) float64 {
+	neighbors := knnr.search.nearest(values, knnr.Configuration.K)
+	weights := knnr.search.Configuration.neighborWeights(neighbors)
+
+	var sum, total float64
+	for ni, neighbor := range neighbors {
+		weight := 1.0
+		if weights != nil {
+			weight = weights[ni]
+		}
+
+		sum += weight * knnr.TrainingData.Records[neighbor.Index].Target
+		total += weight
+	}
+
+	return sum / total
+}
@@ -0,0 +1,160 @@
+package classifiers_test
+
+import (
+	"math"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ScarletTanager/basilisk/classifiers"
+	"github.com/ScarletTanager/wyvern"
+)
+
+var _ = Describe("KnnRegressor", func() {
+	var (
+		knnr *classifiers.KNearestNeighborRegressor
+		ds   *classifiers.RegressionDataSet
+		cfg  classifiers.KNearestNeighborRegressorConfig
+	)
+
+	BeforeEach(func() {
+		ds, _ = classifiers.NewRegressionDataSet("y", []string{"x"}, []classifiers.RegressionRecord{
+			{Target: 0, AttributeValues: wyvern.Vector[float64]{0}},
+			{Target: 10, AttributeValues: wyvern.Vector[float64]{1}},
+			{Target: 20, AttributeValues: wyvern.Vector[float64]{2}},
+			{Target: 60, AttributeValues: wyvern.Vector[float64]{6}},
+		})
+		cfg = classifiers.KNearestNeighborRegressorConfig{K: 2}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		knnr, err = classifiers.NewKnnRegressorFromConfig(cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(knnr.TrainFromDataset(ds, &classifiers.DataSplitConfig{TrainingShare: 1})).To(Succeed())
+	})
+
+	It("Applies the defaults", func() {
+		Expect(knnr.Configuration.DistanceMethod).To(Equal(classifiers.DistanceMethod_Euclidean))
+		Expect(knnr.Configuration.Weighting).To(Equal(classifiers.Weighting_Uniform))
+		Expect(knnr.Configuration.Index).To(Equal(classifiers.IndexMethod_BruteForce))
+	})
+
+	It("Predicts the mean target of the K nearest neighbors", func() {
+		Expect(knnr.Predict(wyvern.Vector[float64]{1.8})).To(Equal(15.0))
+	})
+
+	When("The neighbors are weighted by inverse distance", func() {
+		BeforeEach(func() {
+			cfg.Weighting = classifiers.Weighting_InverseDistance
+		})
+
+		It("Predicts the weighted mean target", func() {
+			// Weights of 1/.2 and 1/.8
+			Expect(knnr.Predict(wyvern.Vector[float64]{1.8})).To(BeNumerically("~", (5*20+1.25*10)/6.25))
+		})
+
+		It("Predicts the target of an exact match", func() {
+			Expect(knnr.Predict(wyvern.Vector[float64]{6})).To(Equal(60.0))
+		})
+	})
+
+	When("The values are the wrong length", func() {
+		It("Returns an error", func() {
+			_, err := knnr.Predict(wyvern.Vector[float64]{1, 2})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("The configuration is invalid", func() {
+		It("Returns nil and an error", func() {
+			r, err := classifiers.NewKnnRegressorFromConfig(classifiers.KNearestNeighborRegressorConfig{K: 0})
+			Expect(err).To(HaveOccurred())
+			Expect(r).To(BeNil())
+		})
+	})
+
+	Describe("Test", func() {
+		JustBeforeEach(func() {
+			Expect(knnr.TrainFromCSVFile("../fixtures/plane.csv", &classifiers.DataSplitConfig{
+				Method: classifiers.SplitSequential,
+			})).To(Succeed())
+		})
+
+		It("Predicts the target of every test record", func() {
+			results, err := knnr.Test()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results).To(HaveLen(len(knnr.TestingData.Records)))
+			for i, result := range results {
+				Expect(result.RegressionRecord).To(Equal(knnr.TestingData.Records[i]))
+			}
+		})
+
+		It("Fits the plane reasonably well", func() {
+			results, _ := knnr.Test()
+			analysis := results.Analyze()
+			Expect(analysis.ResultCount).To(Equal(len(results)))
+			Expect(analysis.RSquared).To(BeNumerically(">", .5))
+		})
+	})
+
+	When("Retraining leaves no training records", func() {
+		It("Returns an error and leaves the model unchanged", func() {
+			trainingData := knnr.TrainingData
+			single, _ := classifiers.NewRegressionDataSet("y", []string{"x"}, []classifiers.RegressionRecord{
+				{Target: 5, AttributeValues: wyvern.Vector[float64]{.5}},
+			})
+			Expect(knnr.TrainFromDataset(single, nil)).To(MatchError(ContainSubstring("no training records")))
+			Expect(knnr.TrainingData).To(BeIdenticalTo(trainingData))
+			Expect(knnr.Predict(wyvern.Vector[float64]{1.8})).To(Equal(15.0))
+		})
+	})
+
+	When("The model has not been trained", func() {
+		It("Returns an error", func() {
+			untrained, _ := classifiers.NewKnnRegressor(1, "")
+			_, err := untrained.Test()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("RegressionResults", func() {
+	Describe("Analyze", func() {
+		result := func(target, predicted float64) classifiers.RegressionResult {
+			return classifiers.RegressionResult{
+				RegressionRecord: classifiers.RegressionRecord{Target: target},
+				Predicted:        predicted,
+			}
+		}
+
+		It("Computes the MAE, RMSE and R²", func() {
+			analysis := classifiers.RegressionResults{
+				result(1, 2),
+				result(2, 2),
+				result(3, 1),
+				result(6, 6),
+			}.Analyze()
+
+			Expect(analysis.ResultCount).To(Equal(4))
+			Expect(analysis.MeanAbsoluteError).To(BeNumerically("~", .75))
+			Expect(analysis.RootMeanSquaredError).To(BeNumerically("~", math.Sqrt(5.0/4)))
+			// The mean target is 3, so the total sum of squares is 4+1+0+9
+			Expect(analysis.RSquared).To(BeNumerically("~", 1-5.0/14))
+		})
+
+		When("Every target is the same", func() {
+			It("Reports an R² of 1 for perfect predictions", func() {
+				Expect(classifiers.RegressionResults{result(2, 2), result(2, 2)}.Analyze().RSquared).To(Equal(1.0))
+			})
+
+			It("Reports an R² of 0 otherwise", func() {
+				Expect(classifiers.RegressionResults{result(2, 2), result(2, 3)}.Analyze().RSquared).To(Equal(0.0))
+			})
+		})
+
+		It("Reports zeros for no results", func() {
+			Expect(classifiers.RegressionResults{}.Analyze()).To(Equal(classifiers.RegressionAnalysis{}))
+		})
+	})
+})
@@ -1,7 +1,14 @@
 package classifiers_test
 
 import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+
 	"github.com/ScarletTanager/basilisk/classifiers"
+	"github.com/ScarletTanager/wyvern"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -32,6 +39,75 @@ var _ = Describe("Knn", func() {
 			Expect(c).NotTo(BeNil())
 		})
 
+		When("Called with an unknown distance method", func() {
+			It("Returns nil and an error", func() {
+				c, err := classifiers.NewKnn(k, "hamming")
+				Expect(err).To(HaveOccurred())
+				Expect(c).To(BeNil())
+			})
+		})
+
+		When("Called without a distance method", func() {
+			It("Uses euclidean distance", func() {
+				c, err := classifiers.NewKnn(k, "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c.Configuration.DistanceMethod).To(Equal(classifiers.DistanceMethod_Euclidean))
+			})
+		})
+
+		When("Called with minkowski distance", func() {
+			It("Defaults p to 2", func() {
+				c, err := classifiers.NewKnn(k, classifiers.DistanceMethod_Minkowski)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c.Configuration.MinkowskiP).To(Equal(classifiers.DEFAULT_MINKOWSKI_P))
+			})
+
+			When("p is less than 1", func() {
+				It("Returns nil and an error", func() {
+					c, err := classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+						K:              k,
+						DistanceMethod: classifiers.DistanceMethod_Minkowski,
+						MinkowskiP:     .5,
+					})
+					Expect(err).To(HaveOccurred())
+					Expect(c).To(BeNil())
+				})
+			})
+		})
+
+		When("Called with cosine distance and a tree index", func() {
+			It("Returns nil and an error", func() {
+				for _, im := range []string{classifiers.IndexMethod_KDTree, classifiers.IndexMethod_BallTree} {
+					c, err := classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+						K:              k,
+						DistanceMethod: classifiers.DistanceMethod_Cosine,
+						Index:          im,
+					})
+					Expect(err).To(HaveOccurred())
+					Expect(c).To(BeNil())
+				}
+			})
+		})
+
+		When("Called with an unknown weighting", func() {
+			It("Returns nil and an error", func() {
+				c, err := classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+					K:         k,
+					Weighting: "favoritism",
+				})
+				Expect(err).To(HaveOccurred())
+				Expect(c).To(BeNil())
+			})
+		})
+
+		When("Called without a weighting", func() {
+			It("Uses uniform weighting", func() {
+				c, err := classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{K: k})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c.Configuration.Weighting).To(Equal(classifiers.Weighting_Uniform))
+			})
+		})
+
 		When("Called with k=0", func() {
 			BeforeEach(func() {
 				k = 0
@@ -123,6 +199,286 @@ var _ = Describe("Knn", func() {
 		})
 	})
 
+	Describe("Distance methods", func() {
+		for _, dm := range []string{
+			classifiers.DistanceMethod_Euclidean,
+			classifiers.DistanceMethod_Manhattan,
+			classifiers.DistanceMethod_Minkowski,
+			classifiers.DistanceMethod_Chebyshev,
+			classifiers.DistanceMethod_Cosine,
+			classifiers.DistanceMethod_Mahalanobis,
+		} {
+			dm := dm
+			When("Classifying with "+dm+" distance", func() {
+				BeforeEach(func() {
+					distanceMethod = dm
+				})
+
+				It("Classifies the iris dataset accurately", func() {
+					Expect(knnc.TrainFromCSVFile("../datasets/iris.csv", cfg)).NotTo(HaveOccurred())
+					results, err := knnc.Test()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(results.Analyze().Accuracy).To(BeNumerically(">", .75))
+				})
+			})
+		}
+
+		When("The covariance of the training data is singular", func() {
+			BeforeEach(func() {
+				distanceMethod = classifiers.DistanceMethod_Mahalanobis
+			})
+
+			It("Returns an error from training", func() {
+				ds, _ := classifiers.NewDataSet([]string{"a", "b"}, []string{"x", "constant"}, []classifiers.Record{
+					{Class: 0, AttributeValues: wyvern.Vector[float64]{1, 5}},
+					{Class: 0, AttributeValues: wyvern.Vector[float64]{2, 5}},
+					{Class: 1, AttributeValues: wyvern.Vector[float64]{8, 5}},
+					{Class: 1, AttributeValues: wyvern.Vector[float64]{9, 5}},
+				})
+				Expect(knnc.TrainFromDataset(ds, &classifiers.DataSplitConfig{TrainingShare: 1})).To(HaveOccurred())
+				Expect(knnc.TrainingData).To(BeNil())
+			})
+		})
+	})
+
+	Describe("AutoK", func() {
+		var (
+			autoK *classifiers.AutoKConfig
+		)
+
+		BeforeEach(func() {
+			path = "../datasets/b_vs_wr_data.json"
+			cfg = &classifiers.DataSplitConfig{Method: classifiers.SplitSequential}
+			autoK = &classifiers.AutoKConfig{MaxK: 9}
+		})
+
+		JustBeforeEach(func() {
+			var err error
+			knnc, err = classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{AutoK: autoK})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(knnc.TrainFromJSONFile(path, cfg)).To(Succeed())
+		})
+
+		It("Scores every K in the range", func() {
+			scores := knnc.Configuration.AutoK.Scores
+			Expect(scores).To(HaveLen(9))
+			for i, score := range scores {
+				Expect(score.K).To(Equal(i + 1))
+				Expect(score.Mean).To(BeNumerically(">", 0))
+				Expect(score.Mean).To(BeNumerically("<=", 1))
+				Expect(score.StdDev).To(BeNumerically(">=", 0))
+			}
+		})
+
+		It("Uses the smallest K with the best score", func() {
+			best := knnc.Configuration.AutoK.Scores[0]
+			for _, score := range knnc.Configuration.AutoK.Scores {
+				if score.Mean > best.Mean {
+					best = score
+				}
+			}
+			Expect(knnc.Configuration.K).To(Equal(best.K))
+		})
+
+		It("Records the scores in the configuration", func() {
+			config, ok := knnc.Config().(classifiers.KNearestNeighborClassifierConfig)
+			Expect(ok).To(BeTrue())
+			Expect(config.AutoK.Scores).To(Equal(knnc.Configuration.AutoK.Scores))
+			Expect(config.AutoK.Folds).To(Equal(classifiers.DEFAULT_AUTO_K_FOLDS))
+			Expect(config.AutoK.Metric).To(Equal(classifiers.AutoKMetric_Accuracy))
+		})
+
+		It("Does not modify the caller's configuration", func() {
+			Expect(autoK.Scores).To(BeNil())
+		})
+
+		When("Scoring by balanced accuracy", func() {
+			BeforeEach(func() {
+				autoK.Metric = classifiers.AutoKMetric_BalancedAccuracy
+			})
+
+			It("Scores every K in the range", func() {
+				Expect(knnc.Configuration.AutoK.Scores).To(HaveLen(9))
+				Expect(knnc.Configuration.K).To(BeNumerically(">=", 1))
+			})
+		})
+
+		When("The configuration is invalid", func() {
+			It("Returns nil and an error", func() {
+				for _, invalid := range []classifiers.AutoKConfig{
+					{MinK: -1},
+					{MinK: 5, MaxK: 3},
+					{Folds: 1},
+					{Metric: "vibes"},
+				} {
+					invalid := invalid
+					c, err := classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{AutoK: &invalid})
+					Expect(err).To(HaveOccurred())
+					Expect(c).To(BeNil())
+				}
+			})
+		})
+	})
+
+	Describe("AttributeWeights", func() {
+		var (
+			ds      *classifiers.DataSet
+			weights map[string]float64
+			query   wyvern.Vector[float64]
+		)
+
+		BeforeEach(func() {
+			ds, _ = classifiers.NewDataSet([]string{"a", "b"}, []string{"x", "y"}, []classifiers.Record{
+				{Class: 0, AttributeValues: wyvern.Vector[float64]{0, 0}},
+				{Class: 1, AttributeValues: wyvern.Vector[float64]{3, 10}},
+			})
+			query = wyvern.Vector[float64]{.5, 9}
+			weights = nil
+			k = 1
+		})
+
+		JustBeforeEach(func() {
+			var err error
+			knnc, err = classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+				K:                k,
+				DistanceMethod:   distanceMethod,
+				AttributeWeights: weights,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Treats the attributes equally by default", func() {
+			Expect(knnc.TrainFromDataset(ds, &classifiers.DataSplitConfig{TrainingShare: 1})).To(Succeed())
+			Expect(knnc.Predict(query)).To(HaveField("ClassName", "b"))
+		})
+
+		When("An attribute is weighted", func() {
+			BeforeEach(func() {
+				weights = map[string]float64{"y": 0}
+			})
+
+			It("Scales the attribute in the distance computation", func() {
+				Expect(knnc.TrainFromDataset(ds, &classifiers.DataSplitConfig{TrainingShare: 1})).To(Succeed())
+				Expect(knnc.Predict(query)).To(HaveField("ClassName", "a"))
+			})
+		})
+
+		When("The distance method is mahalanobis", func() {
+			BeforeEach(func() {
+				distanceMethod = classifiers.DistanceMethod_Mahalanobis
+				ds, _ = classifiers.NewDataSet([]string{"a", "b"}, []string{"x", "y"}, []classifiers.Record{
+					{Class: 0, AttributeValues: wyvern.Vector[float64]{0, 0}},
+					{Class: 0, AttributeValues: wyvern.Vector[float64]{1, 2}},
+					{Class: 1, AttributeValues: wyvern.Vector[float64]{3, 10}},
+					{Class: 1, AttributeValues: wyvern.Vector[float64]{4, 9}},
+				})
+				query = wyvern.Vector[float64]{2.5, 3}
+			})
+
+			It("Treats the attributes equally by default", func() {
+				Expect(knnc.TrainFromDataset(ds, &classifiers.DataSplitConfig{TrainingShare: 1})).To(Succeed())
+				Expect(knnc.Predict(query)).To(HaveField("ClassName", "b"))
+			})
+
+			When("An attribute is weighted", func() {
+				BeforeEach(func() {
+					weights = map[string]float64{"y": 5}
+				})
+
+				It("Scales the attribute in the distance computation", func() {
+					Expect(knnc.TrainFromDataset(ds, &classifiers.DataSplitConfig{TrainingShare: 1})).To(Succeed())
+					Expect(knnc.Predict(query)).To(HaveField("ClassName", "a"))
+				})
+			})
+
+			When("An attribute has a weight of 0", func() {
+				BeforeEach(func() {
+					weights = map[string]float64{"y": 0}
+					query = wyvern.Vector[float64]{.5, 9}
+				})
+
+				It("Ignores the attribute", func() {
+					Expect(knnc.TrainFromDataset(ds, &classifiers.DataSplitConfig{TrainingShare: 1})).To(Succeed())
+					Expect(knnc.Predict(query)).To(HaveField("ClassName", "a"))
+				})
+			})
+		})
+
+		When("The weights are applied with a tree index", func() {
+			var (
+				expected classifiers.TestResults
+			)
+
+			BeforeEach(func() {
+				path = "../datasets/b_vs_wr_data.json"
+				cfg = &classifiers.DataSplitConfig{Method: classifiers.SplitSequential}
+				weights = map[string]float64{"wing": 3, "length": .25}
+
+				bruteC, _ := classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+					K:                3,
+					AttributeWeights: weights,
+				})
+				Expect(bruteC.TrainFromJSONFile(path, cfg)).To(Succeed())
+				expected, _ = bruteC.Test()
+			})
+
+			It("Returns the same results as the brute force search", func() {
+				for _, im := range []string{classifiers.IndexMethod_KDTree, classifiers.IndexMethod_BallTree} {
+					c, _ := classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+						K:                3,
+						AttributeWeights: weights,
+						Index:            im,
+					})
+					Expect(c.TrainFromJSONFile(path, cfg)).To(Succeed())
+					Expect(c.Test()).To(Equal(expected))
+				}
+			})
+		})
+
+		When("A weighted attribute is not in the data", func() {
+			BeforeEach(func() {
+				weights = map[string]float64{"z": 2}
+			})
+
+			It("Returns an error from training and leaves the model untrained", func() {
+				Expect(knnc.TrainFromDataset(ds, nil)).To(MatchError(ContainSubstring("z")))
+				Expect(knnc.TrainingData).To(BeNil())
+				_, err := knnc.Predict(query)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		When("A trained model is retrained on data without a weighted attribute", func() {
+			BeforeEach(func() {
+				weights = map[string]float64{"y": 0}
+			})
+
+			It("Returns an error from training and leaves the model unchanged", func() {
+				Expect(knnc.TrainFromDataset(ds, &classifiers.DataSplitConfig{TrainingShare: 1})).To(Succeed())
+				trainingData := knnc.TrainingData
+
+				other, _ := classifiers.NewDataSet([]string{"a", "b"}, []string{"x", "z"}, []classifiers.Record{
+					{Class: 1, AttributeValues: wyvern.Vector[float64]{0, 0}},
+					{Class: 0, AttributeValues: wyvern.Vector[float64]{3, 10}},
+				})
+				Expect(knnc.TrainFromDataset(other, &classifiers.DataSplitConfig{TrainingShare: 1})).NotTo(Succeed())
+				Expect(knnc.TrainingData).To(BeIdenticalTo(trainingData))
+				Expect(knnc.Predict(query)).To(HaveField("ClassName", "a"))
+			})
+		})
+
+		When("A weight is negative", func() {
+			It("Returns nil and an error", func() {
+				c, err := classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+					K:                k,
+					AttributeWeights: map[string]float64{"x": -1},
+				})
+				Expect(err).To(HaveOccurred())
+				Expect(c).To(BeNil())
+			})
+		})
+	})
+
 	Describe("Test", func() {
 		BeforeEach(func() {
 			path = "../fixtures/students.csv"
@@ -158,6 +514,20 @@ var _ = Describe("Knn", func() {
 				}
 			})
 
+			It("Reports the probability of every class", func() {
+				results, _ := knnc.Test()
+				for _, res := range results {
+					Expect(res.Probabilities).To(HaveLen(len(knnc.TrainingData.ClassNames)))
+					total := 0.0
+					for _, className := range knnc.TrainingData.ClassNames {
+						Expect(res.Probabilities).To(HaveKey(className))
+						total += res.Probabilities[className]
+					}
+					Expect(total).To(BeNumerically("~", 1.0))
+					Expect(res.Probabilities[knnc.TrainingData.ClassNames[res.Predicted]]).To(Equal(res.Probability))
+				}
+			})
+
 			It("Predicts the class based on the k nearest neighbors", func() {
 				results, _ := knnc.Test()
 				a := results.Analyze()
@@ -172,5 +542,918 @@ var _ = Describe("Knn", func() {
 				Expect(err).To(HaveOccurred())
 			})
 		})
+
+		When("Classifying a record panics", func() {
+			It("Returns the panic as an error", func() {
+				// Distances are only computed when classifying, not when training a brute force index
+				Expect(classifiers.RegisterDistance(classifiers.DistanceInfo{Name: "panicking"}, func(a, b wyvern.Vector[float64]) float64 {
+					panic("distance unavailable")
+				})).To(Succeed())
+
+				c, err := classifiers.NewKnn(k, "panicking")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c.TrainFromCSVFile(path, cfg)).To(Succeed())
+
+				_, err = c.Test()
+				Expect(err).To(MatchError(ContainSubstring("distance unavailable")))
+			})
+		})
+	})
+
+	Describe("TestExplained", func() {
+		BeforeEach(func() {
+			path = "../fixtures/students.csv"
+			cfg = &classifiers.DataSplitConfig{
+				Method: classifiers.SplitSequential,
+			}
+		})
+
+		When("The model has been trained", func() {
+			JustBeforeEach(func() {
+				Expect(knnc.TrainFromCSVFile(path, cfg)).NotTo(HaveOccurred())
+			})
+
+			It("Returns the same predictions as Test", func() {
+				explained, err := knnc.TestExplained(context.Background())
+				Expect(err).NotTo(HaveOccurred())
+				results, _ := knnc.Test()
+				Expect(explained).To(HaveLen(len(results)))
+				for i, res := range results {
+					Expect(explained[i].Predicted).To(Equal(res.Predicted))
+					Expect(explained[i].Probabilities).To(Equal(res.Probabilities))
+					Expect(res.Neighbors).To(BeNil())
+				}
+			})
+
+			It("Lists the K nearest neighbors of each record, nearest first", func() {
+				results, _ := knnc.TestExplained(context.Background())
+				for _, res := range results {
+					Expect(res.Neighbors).To(HaveLen(k))
+					votes := 0
+					for i, neighbor := range res.Neighbors {
+						training := knnc.TrainingData.Records[neighbor.Index]
+						Expect(neighbor.ClassName).To(Equal(knnc.TrainingData.ClassNames[training.Class]))
+						Expect(neighbor.AttributeValues).To(Equal(training.AttributeValues))
+						Expect(neighbor.Distance).To(BeNumerically("~", classifiers.EuclideanDistance(res.AttributeValues, training.AttributeValues)))
+						if i > 0 {
+							Expect(neighbor.Distance).To(BeNumerically(">=", res.Neighbors[i-1].Distance))
+						}
+						if training.Class == res.Predicted {
+							votes++
+						}
+					}
+					Expect(votes).To(Equal(res.Votes))
+				}
+			})
+		})
+
+		When("The model has not been trained", func() {
+			It("Returns an error", func() {
+				_, err := knnc.TestExplained(context.Background())
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("Predict", func() {
+		var (
+			values wyvern.Vector[float64]
+		)
+
+		BeforeEach(func() {
+			path = "../fixtures/students.csv"
+			cfg = &classifiers.DataSplitConfig{
+				Method: classifiers.SplitSequential,
+			}
+			values = wyvern.Vector[float64]{8.5}
+		})
+
+		When("The model has been trained", func() {
+			JustBeforeEach(func() {
+				Expect(knnc.TrainFromCSVFile(path, cfg)).NotTo(HaveOccurred())
+			})
+
+			It("Predicts the class of the record", func() {
+				p, err := knnc.Predict(values)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(p.ClassName).To(Equal("Elementary"))
+				Expect(knnc.TrainingData.ClassNames[p.Class]).To(Equal(p.ClassName))
+				Expect(p.Probability).To(Equal(1.0))
+				Expect(p.Votes).To(Equal(k))
+			})
+
+			It("Includes the probability of every class", func() {
+				p, _ := knnc.Predict(values)
+				Expect(p.Probabilities).To(Equal(map[string]float64{
+					"Elementary": 1.0,
+					"Middle":     0.0,
+					"High":       0.0,
+				}))
+			})
+
+			When("The record has the wrong number of attribute values", func() {
+				BeforeEach(func() {
+					values = wyvern.Vector[float64]{8.5, 3.0}
+				})
+
+				It("Returns an error", func() {
+					_, err := knnc.Predict(values)
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
+
+		When("The model has not been trained", func() {
+			It("Returns an error", func() {
+				_, err := knnc.Predict(values)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("PredictBatch", func() {
+		var (
+			batch []wyvern.Vector[float64]
+		)
+
+		BeforeEach(func() {
+			path = "../fixtures/students.csv"
+			cfg = &classifiers.DataSplitConfig{
+				Method: classifiers.SplitSequential,
+			}
+			batch = []wyvern.Vector[float64]{{8.5}, {12.8}, {17.0}}
+		})
+
+		JustBeforeEach(func() {
+			Expect(knnc.TrainFromCSVFile(path, cfg)).NotTo(HaveOccurred())
+		})
+
+		It("Returns a prediction for each record, in order", func() {
+			predictions, err := knnc.PredictBatch(batch)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(predictions).To(HaveLen(len(batch)))
+			Expect(predictions[0].ClassName).To(Equal("Elementary"))
+			Expect(predictions[1].ClassName).To(Equal("Middle"))
+			Expect(predictions[2].ClassName).To(Equal("High"))
+		})
+
+		When("Any record in the batch is invalid", func() {
+			BeforeEach(func() {
+				batch[1] = wyvern.Vector[float64]{}
+			})
+
+			It("Returns nil and an error", func() {
+				predictions, err := knnc.PredictBatch(batch)
+				Expect(err).To(HaveOccurred())
+				Expect(predictions).To(BeNil())
+			})
+		})
+	})
+
+	Describe("Weighting", func() {
+		var (
+			weighting string
+			bandwidth float64
+			ds        *classifiers.DataSet
+		)
+
+		BeforeEach(func() {
+			bandwidth = 1.0
+			// One "near" record far outvoted by two "far" records
+			ds, _ = classifiers.NewDataSet([]string{"near", "far"}, []string{"x"}, []classifiers.Record{
+				{Class: 0, AttributeValues: wyvern.Vector[float64]{0.0}},
+				{Class: 1, AttributeValues: wyvern.Vector[float64]{5.0}},
+				{Class: 1, AttributeValues: wyvern.Vector[float64]{5.1}},
+				{Class: 0, AttributeValues: wyvern.Vector[float64]{0.2}},
+			})
+			cfg = &classifiers.DataSplitConfig{
+				Method:        classifiers.SplitSequential,
+				TrainingShare: .75,
+			}
+		})
+
+		JustBeforeEach(func() {
+			var err error
+			knnc, err = classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+				K:         3,
+				Weighting: weighting,
+				Bandwidth: bandwidth,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(knnc.TrainFromDataset(ds, cfg)).NotTo(HaveOccurred())
+		})
+
+		When("Votes are uniform", func() {
+			BeforeEach(func() {
+				weighting = classifiers.Weighting_Uniform
+			})
+
+			It("Predicts the majority class of the neighbors", func() {
+				results, _ := knnc.Test()
+				Expect(results[0].Predicted).To(Equal(1))
+				Expect(results[0].Probability).To(BeNumerically("~", 2.0/3.0))
+			})
+		})
+
+		for _, w := range []string{classifiers.Weighting_InverseDistance, classifiers.Weighting_InverseSquaredDistance, classifiers.Weighting_Gaussian} {
+			w := w
+			When("Votes are weighted by "+w, func() {
+				BeforeEach(func() {
+					weighting = w
+				})
+
+				It("Lets the closest neighbor outvote the others", func() {
+					results, _ := knnc.Test()
+					Expect(results[0].Predicted).To(Equal(0))
+					Expect(results[0].Votes).To(Equal(1))
+					Expect(results[0].Probabilities["near"] + results[0].Probabilities["far"]).To(BeNumerically("~", 1.0))
+					Expect(results[0].Probability).To(Equal(results[0].Probabilities["near"]))
+				})
+			})
+		}
+
+		When("A neighbor is an exact match and votes are weighted by inverse distance", func() {
+			BeforeEach(func() {
+				weighting = classifiers.Weighting_InverseDistance
+				ds.Records[3].AttributeValues[0] = 0.0
+			})
+
+			It("Gives the exact match all of the weight", func() {
+				results, _ := knnc.Test()
+				Expect(results[0].Predicted).To(Equal(0))
+				Expect(results[0].Probability).To(Equal(1.0))
+			})
+		})
+
+		When("The gaussian bandwidth is not set", func() {
+			BeforeEach(func() {
+				weighting = classifiers.Weighting_Gaussian
+				bandwidth = 0
+			})
+
+			It("Uses the distance to the Kth neighbor as the bandwidth", func() {
+				results, _ := knnc.Test()
+				// The distances are .2, 4.8 and 4.9, so the bandwidth is 4.9
+				h := 2 * 4.9 * 4.9
+				near := math.Exp(-(.2 * .2) / h)
+				far := math.Exp(-(4.8*4.8)/h) + math.Exp(-(4.9*4.9)/h)
+				Expect(results[0].Probabilities["near"]).To(BeNumerically("~", near/(near+far)))
+			})
+		})
+	})
+
+	Describe("TieBreak", func() {
+		var (
+			tieBreak string
+			ds       *classifiers.DataSet
+		)
+
+		BeforeEach(func() {
+			// With K=2, the test record's neighbors are one "near" and one "far" record,
+			// and "far" has the lower class index
+			ds, _ = classifiers.NewDataSet([]string{"far", "near"}, []string{"x"}, []classifiers.Record{
+				{Class: 1, AttributeValues: wyvern.Vector[float64]{0.0}},
+				{Class: 0, AttributeValues: wyvern.Vector[float64]{1.0}},
+				{Class: 1, AttributeValues: wyvern.Vector[float64]{2.0}},
+				{Class: 1, AttributeValues: wyvern.Vector[float64]{0.4}},
+			})
+			cfg = &classifiers.DataSplitConfig{
+				Method:        classifiers.SplitSequential,
+				TrainingShare: .75,
+			}
+		})
+
+		JustBeforeEach(func() {
+			var err error
+			knnc, err = classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+				K:        2,
+				TieBreak: tieBreak,
+				Seed:     99,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(knnc.TrainFromDataset(ds, cfg)).NotTo(HaveOccurred())
+		})
+
+		expectPrediction := func(policy string, expected int) {
+			When("The policy is "+policy, func() {
+				BeforeEach(func() {
+					tieBreak = policy
+				})
+
+				It("Flags the tie and resolves it according to the policy", func() {
+					results, _ := knnc.Test()
+					Expect(results[0].Tied).To(BeTrue())
+					Expect(results[0].Predicted).To(Equal(expected))
+				})
+			})
+		}
+
+		expectPrediction(classifiers.TieBreak_LowestIndex, 0)
+		expectPrediction(classifiers.TieBreak_Nearest, 1)
+		expectPrediction(classifiers.TieBreak_LowestTotalDistance, 1)
+		expectPrediction(classifiers.TieBreak_ExpandK, 1)
+		expectPrediction(classifiers.TieBreak_None, classifiers.NO_PREDICTION)
+
+		When("The policy is random", func() {
+			BeforeEach(func() {
+				tieBreak = classifiers.TieBreak_Random
+			})
+
+			It("Chooses one of the tied classes, repeatably", func() {
+				first, _ := knnc.Test()
+				Expect(first[0].Predicted).To(BeElementOf(0, 1))
+				second, _ := knnc.Test()
+				Expect(second[0].Predicted).To(Equal(first[0].Predicted))
+			})
+		})
+
+		When("K is expanded", func() {
+			BeforeEach(func() {
+				tieBreak = classifiers.TieBreak_ExpandK
+			})
+
+			It("Reports the votes of the expanded neighborhood", func() {
+				results, _ := knnc.Test()
+				Expect(results[0].Votes).To(Equal(2))
+				Expect(results[0].Probability).To(BeNumerically("~", 2.0/3.0))
+			})
+		})
+
+		When("K is expanded past the neighbors an hnsw index finds", func() {
+			It("Stops expanding and lets the nearest neighbor decide", func() {
+				// Every record is identical and in a class of its own, so the tie is never broken
+				classNames := make([]string, 120)
+				records := make([]classifiers.Record, 120)
+				for i := range records {
+					classNames[i] = fmt.Sprintf("c%d", i)
+					records[i] = classifiers.Record{Class: i, AttributeValues: wyvern.Vector[float64]{1, 1}}
+				}
+				ds, _ := classifiers.NewDataSet(classNames, []string{"x", "y"}, records)
+
+				c, err := classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+					K:        2,
+					TieBreak: classifiers.TieBreak_ExpandK,
+					Index:    classifiers.IndexMethod_HNSW,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c.TrainFromDataset(ds, &classifiers.DataSplitConfig{TrainingShare: 1})).To(Succeed())
+
+				prediction, err := c.Predict(wyvern.Vector[float64]{1, 1})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(prediction.Tied).To(BeTrue())
+				Expect(prediction.Votes).To(Equal(1))
+			})
+		})
+
+		When("The policy is unknown", func() {
+			It("Cannot be used to create a classifier", func() {
+				c, err := classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{K: 2, TieBreak: "coin_toss"})
+				Expect(err).To(HaveOccurred())
+				Expect(c).To(BeNil())
+			})
+		})
+
+		When("There is no tie", func() {
+			BeforeEach(func() {
+				tieBreak = classifiers.TieBreak_None
+				ds.Records[3].AttributeValues[0] = 0.1
+				ds.Records[1].AttributeValues[0] = 0.2
+				ds.Records[1].Class = 1
+			})
+
+			It("Does not flag a tie", func() {
+				results, _ := knnc.Test()
+				Expect(results[0].Tied).To(BeFalse())
+				Expect(results[0].Predicted).To(Equal(1))
+			})
+		})
+	})
+
+	Describe("Concurrency", func() {
+		var (
+			concurrency int
+		)
+
+		BeforeEach(func() {
+			path = "../datasets/b_vs_wr_data.json"
+			cfg = &classifiers.DataSplitConfig{
+				Method: classifiers.SplitSequential,
+			}
+			concurrency = 0
+		})
+
+		JustBeforeEach(func() {
+			var err error
+			knnc, err = classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+				K:           k,
+				Concurrency: concurrency,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(knnc.TrainFromJSONFile(path, cfg)).NotTo(HaveOccurred())
+		})
+
+		It("Defaults to GOMAXPROCS", func() {
+			Expect(knnc.Configuration.Concurrency).To(Equal(runtime.GOMAXPROCS(0)))
+		})
+
+		When("Testing on several workers", func() {
+			BeforeEach(func() {
+				concurrency = 8
+			})
+
+			It("Returns the results in the order of the test records", func() {
+				results, err := knnc.Test()
+				Expect(err).NotTo(HaveOccurred())
+
+				sequential, _ := classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+					K:           k,
+					Concurrency: 1,
+				})
+				Expect(sequential.TrainFromJSONFile(path, cfg)).NotTo(HaveOccurred())
+				Expect(sequential.Test()).To(Equal(results))
+			})
+		})
+
+		When("The context is cancelled", func() {
+			It("Returns the context's error", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				results, err := knnc.TestContext(ctx)
+				Expect(err).To(MatchError(context.Canceled))
+				Expect(results).To(BeNil())
+			})
+		})
+
+		When("The concurrency is negative", func() {
+			It("Returns nil and an error", func() {
+				c, err := classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+					K:           k,
+					Concurrency: -1,
+				})
+				Expect(err).To(HaveOccurred())
+				Expect(c).To(BeNil())
+			})
+		})
+	})
+
+	Describe("Index", func() {
+		var (
+			index  string
+			bruteC *classifiers.KNearestNeighborClassifier
+		)
+
+		BeforeEach(func() {
+			path = "../datasets/b_vs_wr_data.json"
+			cfg = &classifiers.DataSplitConfig{
+				Method: classifiers.SplitSequential,
+			}
+			k = 5
+			index = classifiers.IndexMethod_BruteForce
+		})
+
+		JustBeforeEach(func() {
+			var err error
+			knnc, err = classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+				K:              k,
+				DistanceMethod: distanceMethod,
+				Index:          index,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(knnc.TrainFromJSONFile(path, cfg)).NotTo(HaveOccurred())
+
+			bruteC, _ = classifiers.NewKnn(k, distanceMethod)
+			Expect(bruteC.TrainFromJSONFile(path, cfg)).NotTo(HaveOccurred())
+		})
+
+		lpMethods := []string{
+			classifiers.DistanceMethod_Euclidean,
+			classifiers.DistanceMethod_Manhattan,
+			classifiers.DistanceMethod_Minkowski,
+			classifiers.DistanceMethod_Chebyshev,
+		}
+		indexDistanceMethods := map[string][]string{
+			classifiers.IndexMethod_KDTree:   lpMethods,
+			classifiers.IndexMethod_BallTree: append(lpMethods[:len(lpMethods):len(lpMethods)], classifiers.DistanceMethod_Mahalanobis),
+		}
+
+		for _, im := range []string{classifiers.IndexMethod_KDTree, classifiers.IndexMethod_BallTree} {
+			im := im
+			for _, dm := range indexDistanceMethods[im] {
+				dm := dm
+				When("Searching a "+im+" with "+dm+" distance", func() {
+					BeforeEach(func() {
+						index = im
+						distanceMethod = dm
+					})
+
+					It("Returns the same results as the brute force search", func() {
+						results, err := knnc.Test()
+						Expect(err).NotTo(HaveOccurred())
+						expected, _ := bruteC.Test()
+						Expect(results).To(Equal(expected))
+					})
+				})
+			}
+		}
+
+		When("The records are scattered at random", func() {
+			var ds *classifiers.DataSet
+
+			BeforeEach(func() {
+				// Random points make many close calls at the splits, which the fixtures do not
+				r := rand.New(rand.NewSource(1))
+				records := make([]classifiers.Record, 400)
+				for i := range records {
+					records[i] = classifiers.Record{
+						Class:           r.Intn(4),
+						AttributeValues: wyvern.Vector[float64]{r.Float64(), r.Float64()},
+					}
+				}
+				ds, _ = classifiers.NewDataSet([]string{"a", "b", "c", "d"}, []string{"x", "y"}, records)
+			})
+
+			for _, im := range []string{classifiers.IndexMethod_KDTree, classifiers.IndexMethod_BallTree} {
+				im := im
+				It("Returns the same results from a "+im+" as the brute force search", func() {
+					indexed, _ := classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{K: k, Index: im})
+					Expect(indexed.TrainFromDataset(ds, cfg)).NotTo(HaveOccurred())
+					brute, _ := classifiers.NewKnn(k, "")
+					Expect(brute.TrainFromDataset(ds, cfg)).NotTo(HaveOccurred())
+
+					results, err := indexed.Test()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(brute.Test()).To(Equal(results))
+				})
+			}
+		})
+
+		When("Searching an hnsw index", func() {
+			BeforeEach(func() {
+				index = classifiers.IndexMethod_HNSW
+			})
+
+			It("Finds nearly all of the exact neighbors", func() {
+				report, err := knnc.IndexRecall()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(report.Index).To(Equal(classifiers.IndexMethod_HNSW))
+				Expect(report.K).To(Equal(k))
+				Expect(report.Queries).To(Equal(len(knnc.TestingData.Records)))
+				Expect(report.Recall).To(BeNumerically(">", .95))
+				Expect(report.PredictionAgreement).To(BeNumerically(">", .95))
+			})
+
+			It("Is built the same way given the same seed", func() {
+				results, err := knnc.Test()
+				Expect(err).NotTo(HaveOccurred())
+
+				other, _ := classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+					K:              k,
+					DistanceMethod: distanceMethod,
+					Index:          index,
+				})
+				Expect(other.TrainFromJSONFile(path, cfg)).NotTo(HaveOccurred())
+				Expect(other.Test()).To(Equal(results))
+			})
+
+			It("Applies the default parameters", func() {
+				Expect(knnc.Configuration.HNSW).To(Equal(classifiers.HNSWConfig{
+					M:              classifiers.DEFAULT_HNSW_M,
+					EfConstruction: classifiers.DEFAULT_HNSW_EF_CONSTRUCTION,
+					EfSearch:       classifiers.DEFAULT_HNSW_EF_SEARCH,
+				}))
+			})
+
+			When("Many of the points are identical", func() {
+				It("Finds every one of them", func() {
+					r := rand.New(rand.NewSource(5))
+					records := make([]classifiers.Record, 0, 151)
+					for i := 0; i < 120; i++ {
+						records = append(records, classifiers.Record{Class: i % 2, AttributeValues: wyvern.Vector[float64]{.5, .5}})
+					}
+					for i := 0; i < 30; i++ {
+						records = append(records, classifiers.Record{Class: i % 2, AttributeValues: wyvern.Vector[float64]{r.Float64(), r.Float64()}})
+					}
+					// The only testing record
+					records = append(records, classifiers.Record{Class: 0, AttributeValues: wyvern.Vector[float64]{.5, .5}})
+					ds, _ := classifiers.NewDataSet([]string{"a", "b"}, []string{"x", "y"}, records)
+
+					c, _ := classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{K: 120, Index: index})
+					Expect(c.TrainFromDataset(ds, &classifiers.DataSplitConfig{Method: classifiers.SplitSequential, TrainingCount: 150})).To(Succeed())
+					report, err := c.IndexRecall()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(report.Recall).To(Equal(1.0))
+				})
+			})
+
+			When("A parameter is negative", func() {
+				It("Returns nil and an error", func() {
+					c, err := classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+						K:     k,
+						Index: index,
+						HNSW:  classifiers.HNSWConfig{EfSearch: -1},
+					})
+					Expect(err).To(HaveOccurred())
+					Expect(c).To(BeNil())
+				})
+			})
+		})
+
+		for _, im := range []string{classifiers.IndexMethod_BruteForce, classifiers.IndexMethod_KDTree, classifiers.IndexMethod_BallTree} {
+			im := im
+			When("Searching a "+im+" index", func() {
+				BeforeEach(func() {
+					index = im
+				})
+
+				It("Reports perfect recall", func() {
+					report, err := knnc.IndexRecall()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(report.Recall).To(Equal(1.0))
+					Expect(report.MinRecall).To(Equal(1.0))
+					Expect(report.PredictionAgreement).To(Equal(1.0))
+				})
+			})
+		}
+
+		When("The index method is unknown", func() {
+			It("Returns nil and an error", func() {
+				c, err := classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+					K:     k,
+					Index: "card_catalog",
+				})
+				Expect(err).To(HaveOccurred())
+				Expect(c).To(BeNil())
+			})
+		})
+	})
+
+	Describe("AddRecords", func() {
+		var (
+			index   string
+			records []classifiers.Record
+		)
+
+		BeforeEach(func() {
+			index = classifiers.IndexMethod_BruteForce
+			k = 5
+		})
+
+		JustBeforeEach(func() {
+			var err error
+			knnc, err = classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+				K:     k,
+				Index: index,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			// Train on the first half of the data, keeping the rest to add afterwards
+			ds, err := classifiers.FromJSONFile("../datasets/b_vs_wr_data.json")
+			Expect(err).NotTo(HaveOccurred())
+			half := len(ds.Records) / 2
+			records = ds.Records[half:]
+			ds.Records = ds.Records[:half]
+			Expect(knnc.TrainFromDataset(ds, &classifiers.DataSplitConfig{
+				Method:        classifiers.SplitSequential,
+				TrainingShare: .5,
+			})).To(Succeed())
+		})
+
+		for _, im := range []string{classifiers.IndexMethod_BruteForce, classifiers.IndexMethod_KDTree, classifiers.IndexMethod_BallTree, classifiers.IndexMethod_HNSW} {
+			im := im
+			When("Records are added to a "+im+" index while the model is in use", func() {
+				BeforeEach(func() {
+					index = im
+				})
+
+				It("Keeps testing and predicting", func() {
+					trainingCount := len(knnc.TrainingData.Records)
+					done := make(chan struct{})
+					go func() {
+						defer GinkgoRecover()
+						defer close(done)
+						for _, r := range records {
+							Expect(knnc.AddRecords([]classifiers.Record{r})).To(Succeed())
+						}
+					}()
+
+					for finished := false; !finished; {
+						select {
+						case <-done:
+							finished = true
+						default:
+						}
+
+						_, err := knnc.Test()
+						Expect(err).NotTo(HaveOccurred())
+						_, err = knnc.Predict(records[0].AttributeValues)
+						Expect(err).NotTo(HaveOccurred())
+					}
+
+					training, _ := knnc.Data()
+					Expect(training.Records).To(HaveLen(trainingCount + len(records)))
+				})
+			})
+		}
+
+		It("Extends the training data, leaving the test data alone", func() {
+			trainingCount := len(knnc.TrainingData.Records)
+			testing := append([]classifiers.Record{}, knnc.TestingData.Records...)
+
+			Expect(knnc.AddRecords(records)).To(Succeed())
+			Expect(knnc.TrainingData.Records).To(HaveLen(trainingCount + len(records)))
+			Expect(knnc.TrainingData.Records[trainingCount:]).To(Equal(records))
+			Expect(knnc.RawData.Records).To(HaveLen(trainingCount + len(testing) + len(records)))
+			Expect(knnc.TestingData.Records).To(Equal(testing))
+		})
+
+		for _, im := range []string{classifiers.IndexMethod_BruteForce, classifiers.IndexMethod_KDTree, classifiers.IndexMethod_BallTree} {
+			im := im
+			When("Searching a "+im+" index", func() {
+				BeforeEach(func() {
+					index = im
+				})
+
+				It("Finds the added records", func() {
+					Expect(knnc.AddRecords(records)).To(Succeed())
+					report, err := knnc.IndexRecall()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(report.Recall).To(Equal(1.0))
+				})
+			})
+		}
+
+		When("Searching an hnsw index", func() {
+			BeforeEach(func() {
+				index = classifiers.IndexMethod_HNSW
+			})
+
+			It("Finds nearly all of the added records", func() {
+				Expect(knnc.AddRecords(records)).To(Succeed())
+				report, err := knnc.IndexRecall()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(report.Recall).To(BeNumerically(">", .95))
+			})
+		})
+
+		When("A record has the wrong number of attribute values", func() {
+			It("Returns an error and adds nothing", func() {
+				trainingCount := len(knnc.TrainingData.Records)
+				records[1].AttributeValues = wyvern.Vector[float64]{1}
+				Expect(knnc.AddRecords(records)).NotTo(Succeed())
+				Expect(knnc.TrainingData.Records).To(HaveLen(trainingCount))
+			})
+		})
+
+		When("A record has an unknown class", func() {
+			It("Returns an error", func() {
+				records[0].Class = len(knnc.TrainingData.ClassNames)
+				Expect(knnc.AddRecords(records[:1])).NotTo(Succeed())
+			})
+		})
+
+		When("The model has not been trained", func() {
+			It("Returns an error", func() {
+				untrained, _ := classifiers.NewKnn(k, "")
+				Expect(untrained.AddRecords(records)).NotTo(Succeed())
+			})
+		})
+
+		Describe("AddDataset", func() {
+			It("Matches the classes by name", func() {
+				names := knnc.TrainingData.ClassNames
+				ds, _ := classifiers.NewDataSet([]string{names[1]}, knnc.TrainingData.AttributeNames, []classifiers.Record{
+					{Class: 0, AttributeValues: knnc.TestingData.Records[0].AttributeValues},
+				})
+				Expect(knnc.AddDataset(ds)).To(Succeed())
+
+				added := knnc.TrainingData.Records[len(knnc.TrainingData.Records)-1]
+				Expect(added.Class).To(Equal(1))
+			})
+
+			When("The dataset has a class which the model does not know", func() {
+				It("Returns an error", func() {
+					ds, _ := classifiers.NewDataSet([]string{"Dunlin"}, knnc.TrainingData.AttributeNames, nil)
+					Expect(knnc.AddDataset(ds)).NotTo(Succeed())
+				})
+			})
+
+			When("The dataset has different attributes", func() {
+				It("Returns an error", func() {
+					ds, _ := classifiers.NewDataSet(knnc.TrainingData.ClassNames, []string{"wingspan"}, nil)
+					Expect(knnc.AddDataset(ds)).NotTo(Succeed())
+				})
+			})
+		})
+	})
+
+	Describe("Reduction", func() {
+		var reduction *classifiers.ReductionConfig
+
+		BeforeEach(func() {
+			path = "../datasets/b_vs_wr_data.json"
+			cfg = &classifiers.DataSplitConfig{
+				Method: classifiers.SplitSequential,
+			}
+			k = 3
+			reduction = &classifiers.ReductionConfig{Method: classifiers.Reduction_Condensed}
+		})
+
+		JustBeforeEach(func() {
+			var err error
+			knnc, err = classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+				K:         k,
+				Reduction: reduction,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(knnc.TrainFromJSONFile(path, cfg)).To(Succeed())
+		})
+
+		for _, method := range []string{classifiers.Reduction_Condensed, classifiers.Reduction_Edited, classifiers.Reduction_EditedCondensed} {
+			method := method
+			When("Reducing by the "+method+" method", func() {
+				BeforeEach(func() {
+					reduction.Method = method
+				})
+
+				It("Keeps a subset of the training data and reports the impact", func() {
+					report := knnc.Configuration.Reduction
+					full, _ := classifiers.NewKnn(k, "")
+					Expect(full.TrainFromJSONFile(path, cfg)).To(Succeed())
+
+					Expect(report.OriginalCount).To(Equal(len(full.TrainingData.Records)))
+					Expect(report.KeptCount).To(Equal(len(knnc.TrainingData.Records)))
+					Expect(report.KeptCount).To(BeNumerically("<", report.OriginalCount))
+					Expect(report.KeptCount).To(BeNumerically(">", 0))
+					Expect(full.TrainingData.Records).To(ContainElements(knnc.TrainingData.Records))
+
+					fullResults, _ := full.Test()
+					Expect(report.AccuracyBefore).To(Equal(fullResults.Analyze().Accuracy))
+					results, _ := knnc.Test()
+					Expect(report.AccuracyAfter).To(Equal(results.Analyze().Accuracy))
+					Expect(report.AccuracyAfter).To(BeNumerically(">", .75))
+				})
+
+				It("Leaves the configuration passed in alone", func() {
+					Expect(reduction.KeptCount).To(BeZero())
+				})
+			})
+		}
+
+		When("Condensing", func() {
+			It("Keeps enough records to classify all of the training data by the nearest neighbor", func() {
+				full, _ := classifiers.NewKnn(k, "")
+				Expect(full.TrainFromJSONFile(path, cfg)).To(Succeed())
+
+				oneNN, _ := classifiers.NewKnn(1, "")
+				Expect(oneNN.TrainFromDataset(knnc.TrainingData, &classifiers.DataSplitConfig{TrainingShare: 1})).To(Succeed())
+				for _, r := range full.TrainingData.Records {
+					Expect(oneNN.Predict(r.AttributeValues)).To(HaveField("Class", r.Class))
+				}
+			})
+		})
+
+		When("Editing removes every record", func() {
+			BeforeEach(func() {
+				reduction = &classifiers.ReductionConfig{Method: classifiers.Reduction_Edited, EditK: 1}
+			})
+
+			It("Returns an error from training and leaves the model unchanged", func() {
+				trainingData := knnc.TrainingData
+				report := *knnc.Configuration.Reduction
+
+				// Every record's nearest neighbors are of the other class
+				records := make([]classifiers.Record, 8)
+				for i := range records {
+					records[i] = classifiers.Record{Class: i % 2, AttributeValues: wyvern.Vector[float64]{float64(i)}}
+				}
+				ds, _ := classifiers.NewDataSet([]string{"a", "b"}, []string{"x"}, records)
+				Expect(knnc.TrainFromDataset(ds, cfg)).To(MatchError(ContainSubstring("removed every training record")))
+				Expect(knnc.TrainingData).To(BeIdenticalTo(trainingData))
+				Expect(*knnc.Configuration.Reduction).To(Equal(report))
+			})
+		})
+
+		When("The method is unknown", func() {
+			It("Returns nil and an error", func() {
+				c, err := classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+					K:         k,
+					Reduction: &classifiers.ReductionConfig{Method: "shrink"},
+				})
+				Expect(err).To(HaveOccurred())
+				Expect(c).To(BeNil())
+			})
+		})
+
+		When("EditK is negative", func() {
+			It("Returns nil and an error", func() {
+				c, err := classifiers.NewKnnFromConfig(classifiers.KNearestNeighborClassifierConfig{
+					K:         k,
+					Reduction: &classifiers.ReductionConfig{Method: classifiers.Reduction_Edited, EditK: -1},
+				})
+				Expect(err).To(HaveOccurred())
+				Expect(c).To(BeNil())
+			})
+		})
 	})
 })
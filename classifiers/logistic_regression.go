@@ -0,0 +1,417 @@
+package classifiers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/ScarletTanager/wyvern"
+)
+
+const (
+	ClassifierType_LogisticRegression string = "Multinomial Logistic Regression Classifier"
+
+	DEFAULT_LEARNING_RATE = 0.1
+	DEFAULT_EPOCHS        = 200
+	DEFAULT_TOLERANCE     = 1e-6
+)
+
+// LogisticRegressionClassifier is a multinomial (softmax) logistic regression model trained
+// by (mini-batch) gradient descent on the cross-entropy loss with L2 regularization.
+//
+// Attributes are standardized (using the mean and standard deviation of the training data)
+// before training, but the weights reported by Weights and Intercepts are in the original
+// units of the attributes.
+type LogisticRegressionClassifier struct {
+	ClassifierImplementation
+	Configuration LogisticRegressionClassifierConfig
+
+	// weights is indexed by class and then by attribute, and applies to standardized values
+	weights     [][]float64
+	biases      []float64
+	means, sds  []float64
+	lossHistory []float64
+}
+
+type LogisticRegressionClassifierConfig struct {
+	// LearningRate is the gradient descent step size - 0 means DEFAULT_LEARNING_RATE
+	LearningRate float64
+	// Epochs is the maximum number of passes over the training data - 0 means DEFAULT_EPOCHS
+	Epochs int
+	// BatchSize is the number of records per gradient step - 0 means the whole training set
+	BatchSize int
+	// L2 is the strength of the L2 (ridge) penalty on the weights
+	L2 float64
+	// Tolerance stops training early once the loss changes by less than this between
+	// epochs - 0 means DEFAULT_TOLERANCE
+	Tolerance float64
+	// Seed seeds the shuffling of records into mini-batches - 0 means a random seed
+	Seed int64
+}
+
+func NewLogisticRegression(cfg LogisticRegressionClassifierConfig) (*LogisticRegressionClassifier, error) {
+	if cfg.LearningRate < 0 || cfg.Epochs < 0 || cfg.BatchSize < 0 || cfg.L2 < 0 || cfg.Tolerance < 0 {
+		return nil, errors.New("Unable to create classifier, learning rate, epochs, batch size, L2 and tolerance cannot be negative")
+	}
+
+	if cfg.LearningRate == 0 {
+		cfg.LearningRate = DEFAULT_LEARNING_RATE
+	}
+
+	if cfg.Epochs == 0 {
+		cfg.Epochs = DEFAULT_EPOCHS
+	}
+
+	if cfg.Tolerance == 0 {
+		cfg.Tolerance = DEFAULT_TOLERANCE
+	}
+
+	return &LogisticRegressionClassifier{
+		Configuration: cfg,
+	}, nil
+}
+
+func (lrc *LogisticRegressionClassifier) Config() interface{} {
+	return lrc.Configuration
+}
+
+func (lrc *LogisticRegressionClassifier) Type() string {
+	return ClassifierType_LogisticRegression
+}
+
+// Weights returns the learned weights, keyed by class name and then by attribute name
+func (lrc *LogisticRegressionClassifier) Weights() map[string]map[string]float64 {
+	if lrc.weights == nil {
+		return nil
+	}
+
+	weights := make(map[string]map[string]float64, len(lrc.weights))
+	for ci, className := range lrc.TrainingData.ClassNames {
+		weights[className] = make(map[string]float64, len(lrc.TrainingData.AttributeNames))
+		for ai, attributeName := range lrc.TrainingData.AttributeNames {
+			weights[className][attributeName] = lrc.weights[ci][ai] / lrc.sds[ai]
+		}
+	}
+
+	return weights
+}
+
+// Intercepts returns the learned intercept (bias) of each class, keyed by class name
+func (lrc *LogisticRegressionClassifier) Intercepts() map[string]float64 {
+	if lrc.weights == nil {
+		return nil
+	}
+
+	intercepts := make(map[string]float64, len(lrc.biases))
+	for ci, className := range lrc.TrainingData.ClassNames {
+		intercept := lrc.biases[ci]
+		for ai := range lrc.means {
+			intercept -= lrc.weights[ci][ai] * lrc.means[ai] / lrc.sds[ai]
+		}
+		intercepts[className] = intercept
+	}
+
+	return intercepts
+}
+
+// LossHistory returns the regularized cross-entropy loss over the training data at the end
+// of each epoch
+func (lrc *LogisticRegressionClassifier) LossHistory() []float64 {
+	return lrc.lossHistory
+}
+
+func (lrc *LogisticRegressionClassifier) TrainFromCSV(data []byte, cfg *DataSplitConfig) error {
+	ds, err := FromCSV(data)
+	if err != nil {
+		return fmt.Errorf("Error training from CSV: %w", err)
+	}
+
+	return lrc.train(ds, cfg)
+}
+
+func (lrc *LogisticRegressionClassifier) TrainFromCSVFile(path string, cfg *DataSplitConfig) error {
+	ds, err := FromCSVFile(path)
+	if err != nil {
+		return fmt.Errorf("Error training from CSV file %s: %w", path, err)
+	}
+
+	return lrc.train(ds, cfg)
+}
+
+func (lrc *LogisticRegressionClassifier) TrainFromDataset(ds *DataSet, cfg *DataSplitConfig) error {
+	return lrc.train(ds, cfg)
+}
+
+func (lrc *LogisticRegressionClassifier) TrainFromJSON(data []byte, cfg *DataSplitConfig) error {
+	ds, err := FromJSON(data)
+	if err != nil {
+		return fmt.Errorf("Error training from JSON: %w", err)
+	}
+
+	return lrc.train(ds, cfg)
+}
+
+func (lrc *LogisticRegressionClassifier) TrainFromJSONFile(path string, cfg *DataSplitConfig) error {
+	ds, err := FromJSONFile(path)
+	if err != nil {
+		return fmt.Errorf("Error training from JSON file: %w", err)
+	}
+
+	return lrc.train(ds, cfg)
+}
+
+func (lrc *LogisticRegressionClassifier) Retrain(cfg *DataSplitConfig) error {
+	return lrc.train(lrc.RawData, cfg)
+}
+
+// train splits the data and fits a new model to the training data, replacing this one only
+// if training succeeds
+func (lrc *LogisticRegressionClassifier) train(ds *DataSet, cfg *DataSplitConfig) error {
+	trained := &LogisticRegressionClassifier{Configuration: lrc.Configuration}
+	if err := trained.split(ds, cfg); err != nil {
+		return err
+	}
+
+	trained.fit()
+	*lrc = *trained
+	return nil
+}
+
+// fit learns the weights and biases from the training data by gradient descent
+func (lrc *LogisticRegressionClassifier) fit() {
+	records := lrc.TrainingData.Records
+	classCount := len(lrc.TrainingData.ClassNames)
+	attributeCount := len(lrc.TrainingData.AttributeNames)
+
+	lrc.fitScaling()
+	standardized := make([][]float64, len(records))
+	for ri, r := range records {
+		standardized[ri] = lrc.standardize(r.AttributeValues)
+	}
+
+	lrc.weights = make([][]float64, classCount)
+	weightGradients := make([][]float64, classCount)
+	for ci := range lrc.weights {
+		lrc.weights[ci] = make([]float64, attributeCount)
+		weightGradients[ci] = make([]float64, attributeCount)
+	}
+	lrc.biases = make([]float64, classCount)
+	biasGradients := make([]float64, classCount)
+	lrc.lossHistory = make([]float64, 0, lrc.Configuration.Epochs)
+
+	batchSize := lrc.Configuration.BatchSize
+	if batchSize == 0 || batchSize > len(records) {
+		batchSize = len(records)
+	}
+
+	seed := lrc.Configuration.Seed
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	order := make([]int, len(records))
+	for i := range order {
+		order[i] = i
+	}
+
+	probabilities := make([]float64, classCount)
+	for epoch := 0; epoch < lrc.Configuration.Epochs; epoch++ {
+		rng.Shuffle(len(order), func(i, j int) {
+			order[i], order[j] = order[j], order[i]
+		})
+
+		for start := 0; start < len(order); start += batchSize {
+			batch := order[start:min(start+batchSize, len(order))]
+
+			for ci := range weightGradients {
+				biasGradients[ci] = 0
+				for ai := range weightGradients[ci] {
+					weightGradients[ci][ai] = 0
+				}
+			}
+
+			for _, ri := range batch {
+				lrc.softmax(standardized[ri], probabilities)
+				for ci, p := range probabilities {
+					residual := p
+					if ci == records[ri].Class {
+						residual -= 1
+					}
+
+					biasGradients[ci] += residual
+					for ai, x := range standardized[ri] {
+						weightGradients[ci][ai] += residual * x
+					}
+				}
+			}
+
+			step := lrc.Configuration.LearningRate / float64(len(batch))
+			for ci := range lrc.weights {
+				lrc.biases[ci] -= step * biasGradients[ci]
+				for ai := range lrc.weights[ci] {
+					lrc.weights[ci][ai] -= step*weightGradients[ci][ai] + lrc.Configuration.LearningRate*lrc.Configuration.L2*lrc.weights[ci][ai]
+				}
+			}
+		}
+
+		loss := lrc.loss(standardized, probabilities)
+		lrc.lossHistory = append(lrc.lossHistory, loss)
+		if epoch > 0 && math.Abs(lrc.lossHistory[epoch-1]-loss) < lrc.Configuration.Tolerance {
+			break
+		}
+	}
+}
+
+// fitScaling computes the mean and standard deviation of each attribute over the training data
+func (lrc *LogisticRegressionClassifier) fitScaling() {
+	attributeCount := len(lrc.TrainingData.AttributeNames)
+	lrc.means = make([]float64, attributeCount)
+	lrc.sds = make([]float64, attributeCount)
+
+	for _, r := range lrc.TrainingData.Records {
+		for ai, v := range r.AttributeValues {
+			lrc.means[ai] += v
+		}
+	}
+
+	for ai := range lrc.means {
+		lrc.means[ai] /= float64(len(lrc.TrainingData.Records))
+	}
+
+	for _, r := range lrc.TrainingData.Records {
+		for ai, v := range r.AttributeValues {
+			lrc.sds[ai] += (v - lrc.means[ai]) * (v - lrc.means[ai])
+		}
+	}
+
+	for ai := range lrc.sds {
+		lrc.sds[ai] = math.Sqrt(lrc.sds[ai] / float64(len(lrc.TrainingData.Records)))
+		// Constant attributes carry no information, leave them unscaled
+		if lrc.sds[ai] == 0 {
+			lrc.sds[ai] = 1
+		}
+	}
+}
+
+func (lrc *LogisticRegressionClassifier) standardize(values wyvern.Vector[float64]) []float64 {
+	standardized := make([]float64, len(values))
+	for ai, v := range values {
+		standardized[ai] = (v - lrc.means[ai]) / lrc.sds[ai]
+	}
+
+	return standardized
+}
+
+// softmax computes the class probabilities for the standardized values into probabilities
+func (lrc *LogisticRegressionClassifier) softmax(standardized []float64, probabilities []float64) {
+	maxScore := math.Inf(-1)
+	for ci := range lrc.weights {
+		probabilities[ci] = lrc.biases[ci]
+		for ai, x := range standardized {
+			probabilities[ci] += lrc.weights[ci][ai] * x
+		}
+		maxScore = math.Max(maxScore, probabilities[ci])
+	}
+
+	var total float64
+	for ci := range probabilities {
+		probabilities[ci] = math.Exp(probabilities[ci] - maxScore)
+		total += probabilities[ci]
+	}
+
+	for ci := range probabilities {
+		probabilities[ci] /= total
+	}
+}
+
+// loss computes the mean cross-entropy over the training records plus the L2 penalty
+func (lrc *LogisticRegressionClassifier) loss(standardized [][]float64, probabilities []float64) float64 {
+	var loss float64
+	for ri, r := range lrc.TrainingData.Records {
+		lrc.softmax(standardized[ri], probabilities)
+		// Clamp to avoid log(0)
+		loss -= math.Log(math.Max(probabilities[r.Class], 1e-15))
+	}
+	loss /= float64(len(standardized))
+
+	var penalty float64
+	for ci := range lrc.weights {
+		for _, w := range lrc.weights[ci] {
+			penalty += w * w
+		}
+	}
+
+	return loss + (lrc.Configuration.L2/2)*penalty
+}
+
+// Test classifies the records of the testing data
+func (lrc *LogisticRegressionClassifier) Test() (TestResults, error) {
+	return lrc.TestContext(context.Background())
+}
+
+// TestContext classifies the records of the testing data, stopping early if the context
+// is cancelled
+func (lrc *LogisticRegressionClassifier) TestContext(ctx context.Context) (TestResults, error) {
+	if lrc.TrainingData == nil || lrc.TestingData == nil {
+		return nil, errors.New("Untestable model")
+	}
+
+	results, err := classifyAll(ctx, lrc.TestingData.Records, 1, func(r Record, _ int) TestResult {
+		return lrc.classifyRecord(r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lrc.Results = results
+	return results, nil
+}
+
+// Predict classifies a single unlabeled record, represented by its attribute values.
+// The values must be in the same order as the AttributeNames of the training data.
+func (lrc *LogisticRegressionClassifier) Predict(values wyvern.Vector[float64]) (Prediction, error) {
+	if err := lrc.checkValues(values); err != nil {
+		return Prediction{}, err
+	}
+
+	return lrc.prediction(lrc.classifyRecord(Record{Class: NO_PREDICTION, AttributeValues: values})), nil
+}
+
+// PredictBatch classifies each of the unlabeled records in the batch.  If any member
+// of the batch is invalid, no predictions are made.
+func (lrc *LogisticRegressionClassifier) PredictBatch(batch []wyvern.Vector[float64]) ([]Prediction, error) {
+	if err := lrc.checkBatch(batch); err != nil {
+		return nil, err
+	}
+
+	predictions := make([]Prediction, len(batch))
+	for i, values := range batch {
+		predictions[i] = lrc.prediction(lrc.classifyRecord(Record{Class: NO_PREDICTION, AttributeValues: values}))
+	}
+
+	return predictions, nil
+}
+
+func (lrc *LogisticRegressionClassifier) classifyRecord(r Record) TestResult {
+	result := TestResult{
+		Record:        r,
+		Predicted:     NO_PREDICTION,
+		Probabilities: make(map[string]float64, len(lrc.TrainingData.ClassNames)),
+	}
+
+	probabilities := make([]float64, len(lrc.TrainingData.ClassNames))
+	lrc.softmax(lrc.standardize(r.AttributeValues), probabilities)
+
+	for ci, className := range lrc.TrainingData.ClassNames {
+		result.Probabilities[className] = probabilities[ci]
+		if result.Predicted == NO_PREDICTION || probabilities[ci] > probabilities[result.Predicted] {
+			result.Predicted = ci
+		}
+	}
+	result.Probability = probabilities[result.Predicted]
+
+	return result
+}
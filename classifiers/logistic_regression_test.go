@@ -0,0 +1,203 @@
+package classifiers_test
+
+import (
+	"github.com/ScarletTanager/basilisk/classifiers"
+	"github.com/ScarletTanager/wyvern"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LogisticRegression", func() {
+	var (
+		lrc         *classifiers.LogisticRegressionClassifier
+		path        string
+		cfg         *classifiers.DataSplitConfig
+		logisticCfg classifiers.LogisticRegressionClassifierConfig
+	)
+
+	BeforeEach(func() {
+		logisticCfg = classifiers.LogisticRegressionClassifierConfig{
+			Seed: 7,
+		}
+		// The classes are interleaved, so a sequential split is representative
+		path = "../fixtures/iris_interleaved.csv"
+		cfg = &classifiers.DataSplitConfig{
+			Method: classifiers.SplitSequential,
+		}
+	})
+
+	JustBeforeEach(func() {
+		lrc, _ = classifiers.NewLogisticRegression(logisticCfg)
+	})
+
+	Describe("New", func() {
+		It("Applies the defaults", func() {
+			c, err := classifiers.NewLogisticRegression(classifiers.LogisticRegressionClassifierConfig{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Configuration.LearningRate).To(Equal(classifiers.DEFAULT_LEARNING_RATE))
+			Expect(c.Configuration.Epochs).To(Equal(classifiers.DEFAULT_EPOCHS))
+			Expect(c.Configuration.Tolerance).To(Equal(classifiers.DEFAULT_TOLERANCE))
+		})
+
+		When("The learning rate is negative", func() {
+			BeforeEach(func() {
+				logisticCfg.LearningRate = -0.5
+			})
+
+			It("Returns nil and an error", func() {
+				c, err := classifiers.NewLogisticRegression(logisticCfg)
+				Expect(err).To(HaveOccurred())
+				Expect(c).To(BeNil())
+			})
+		})
+	})
+
+	Describe("Train", func() {
+		var single *classifiers.DataSet
+
+		BeforeEach(func() {
+			ds, err := classifiers.FromCSVFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			// Too few records for the split to leave any for training
+			single, err = classifiers.NewDataSet(ds.ClassNames, ds.AttributeNames, ds.Records[:1])
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		When("The data leaves no training records", func() {
+			It("Returns an error and leaves the model untrained", func() {
+				Expect(lrc.TrainFromDataset(single, cfg)).To(MatchError(ContainSubstring("no training records")))
+				Expect(lrc.RawData).To(BeNil())
+				Expect(lrc.TrainingData).To(BeNil())
+				_, err := lrc.Test()
+				Expect(err).To(HaveOccurred())
+			})
+
+			When("The model has already been trained", func() {
+				JustBeforeEach(func() {
+					Expect(lrc.TrainFromCSVFile(path, cfg)).To(Succeed())
+				})
+
+				It("Returns an error and leaves the model unchanged", func() {
+					trainingData := lrc.TrainingData
+					before, err := lrc.Test()
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(lrc.TrainFromDataset(single, cfg)).To(MatchError(ContainSubstring("no training records")))
+					Expect(lrc.TrainingData).To(BeIdenticalTo(trainingData))
+					after, err := lrc.Test()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(after).To(Equal(before))
+				})
+			})
+		})
+	})
+
+	Describe("Test", func() {
+		When("The model has been trained", func() {
+			JustBeforeEach(func() {
+				Expect(lrc.TrainFromCSVFile(path, cfg)).NotTo(HaveOccurred())
+			})
+
+			It("Classifies most of the testing records correctly", func() {
+				results, err := lrc.Test()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(results.Analyze().Accuracy).To(BeNumerically(">", 0.85))
+			})
+
+			It("Reports the probability of every class", func() {
+				results, _ := lrc.Test()
+				for _, res := range results {
+					total := 0.0
+					for _, p := range res.Probabilities {
+						total += p
+					}
+					Expect(total).To(BeNumerically("~", 1.0))
+				}
+			})
+
+			When("Trained with mini-batches", func() {
+				BeforeEach(func() {
+					logisticCfg.BatchSize = 16
+				})
+
+				It("Classifies most of the testing records correctly", func() {
+					results, _ := lrc.Test()
+					Expect(results.Analyze().Accuracy).To(BeNumerically(">", 0.85))
+				})
+			})
+		})
+
+		When("The model has not been trained", func() {
+			It("Returns an error", func() {
+				_, err := lrc.Test()
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("LossHistory", func() {
+		BeforeEach(func() {
+			logisticCfg.Epochs = 50
+			logisticCfg.Tolerance = 1e-12
+		})
+
+		JustBeforeEach(func() {
+			Expect(lrc.TrainFromCSVFile(path, cfg)).NotTo(HaveOccurred())
+		})
+
+		It("Records the loss after each epoch", func() {
+			Expect(lrc.LossHistory()).To(HaveLen(50))
+		})
+
+		It("Decreases as training progresses", func() {
+			history := lrc.LossHistory()
+			Expect(history[len(history)-1]).To(BeNumerically("<", history[0]))
+		})
+
+		When("The tolerance is large", func() {
+			BeforeEach(func() {
+				logisticCfg.Tolerance = 1.0
+			})
+
+			It("Stops training early", func() {
+				Expect(len(lrc.LossHistory())).To(BeNumerically("<", 50))
+			})
+		})
+	})
+
+	Describe("Weights", func() {
+		JustBeforeEach(func() {
+			Expect(lrc.TrainFromCSVFile(path, cfg)).NotTo(HaveOccurred())
+		})
+
+		It("Reports a weight for every class and attribute", func() {
+			weights := lrc.Weights()
+			Expect(weights).To(HaveLen(len(lrc.TrainingData.ClassNames)))
+			for _, className := range lrc.TrainingData.ClassNames {
+				Expect(weights[className]).To(HaveLen(len(lrc.TrainingData.AttributeNames)))
+			}
+			Expect(lrc.Intercepts()).To(HaveLen(len(lrc.TrainingData.ClassNames)))
+		})
+
+		It("Reproduces the predicted probabilities in the original units", func() {
+			values := wyvern.Vector[float64]{5.0, 3.4, 1.5, 0.2}
+			p, err := lrc.Predict(values)
+			Expect(err).NotTo(HaveOccurred())
+
+			weights, intercepts := lrc.Weights(), lrc.Intercepts()
+			scores := make(map[string]float64)
+			for _, className := range lrc.TrainingData.ClassNames {
+				scores[className] = intercepts[className]
+				for ai, attributeName := range lrc.TrainingData.AttributeNames {
+					scores[className] += weights[className][attributeName] * values[ai]
+				}
+			}
+
+			for className, score := range scores {
+				if className != p.ClassName {
+					Expect(score).To(BeNumerically("<", scores[p.ClassName]))
+				}
+			}
+		})
+	})
+})
@@ -1,7 +1,7 @@
 package classifiers
 
 import (
-	"math"
+	"context"
 
 	"github.com/ScarletTanager/wyvern"
 )
@@ -19,8 +19,13 @@ type Classifier interface {
 	TrainFromJSONFile(string, *DataSplitConfig) error
 	Retrain(*DataSplitConfig) error
 	Test() (TestResults, error)
+	// TestContext is Test, but stops (returning the context's error) if the context is cancelled
+	TestContext(context.Context) (TestResults, error)
+	Predict(wyvern.Vector[float64]) (Prediction, error)
+	PredictBatch([]wyvern.Vector[float64]) ([]Prediction, error)
 	Type() string
 	Data() (*DataSet, *DataSet)
+	Raw() *DataSet
 	Config() interface{}
 }
 
@@ -32,6 +37,27 @@ type TestResult struct {
 	Probability float64
 	// Votes is the number of votes (in a nearest neighbors model) for the predicated class
 	Votes int
+	// Probabilities holds the probability of every class, keyed by class name
+	Probabilities map[string]float64
+	// Tied is true if two or more classes received the highest share of the vote
+	Tied bool
+	// Neighbors holds the neighbors which voted on the prediction, and is only populated
+	// when a nearest neighbors model explains its results
+	Neighbors []ExplainedNeighbor `json:",omitempty"`
+}
+
+// Prediction is the classification of a single unlabeled record
+type Prediction struct {
+	// Class is the index of the predicted class, or NO_PREDICTION
+	Class int `json:"class"`
+	// ClassName is empty if no prediction could be made
+	ClassName   string  `json:"class_name"`
+	Probability float64 `json:"probability"`
+	Votes       int     `json:"votes"`
+	// Probabilities holds the probability of every class, keyed by class name
+	Probabilities map[string]float64 `json:"probabilities"`
+	// Tied is true if two or more classes received the highest share of the vote
+	Tied bool `json:"tied"`
 }
 
 type TestResultsAnalysis struct {
@@ -39,6 +65,15 @@ type TestResultsAnalysis struct {
 	CorrectCount   int     `json:"correct"`
 	IncorrectCount int     `json:"incorrect"`
 	Accuracy       float64 `json:"accuracy"`
+	// The remaining fields are only filled in by AnalyzeClasses
+	ConfusionMatrix *ConfusionMatrix `json:"confusion_matrix,omitempty"`
+	// Classes holds the metrics of each class, in the order of the class names
+	Classes         []ClassMetrics  `json:"classes,omitempty"`
+	MacroAverage    *AverageMetrics `json:"macro_average,omitempty"`
+	MicroAverage    *AverageMetrics `json:"micro_average,omitempty"`
+	WeightedAverage *AverageMetrics `json:"weighted_average,omitempty"`
+	Calibration     *Calibration    `json:"calibration,omitempty"`
+	Agreement       *Agreement      `json:"agreement,omitempty"`
 }
 
 func (trs TestResults) Analyze() TestResultsAnalysis {
@@ -58,33 +93,3 @@ func (trs TestResults) Analyze() TestResultsAnalysis {
 
 	return analysis
 }
-
-const (
-	DistanceMethod_Euclidean = "euclidean"
-	DistanceMethod_Manhattan = "manhattan"
-)
-
-type DistanceFunction func(wyvern.Vector[float64], wyvern.Vector[float64]) float64
-
-// For both distance functions, we're assuming that the vectors have the same
-// dimensionality (number of components).  Don't use these with vectors that
-// don't have the same dimensionality and expect things to "just work."
-
-// EuclideanDistance is (for now) just a convenience method to return
-// euclidean distance - we will probably change the implementation if/when
-// we support attribute types other than float64
-func EuclideanDistance(a, b wyvern.Vector[float64]) float64 {
-	return a.Difference(b).Magnitude()
-}
-
-// ManhattanDistance returns the Manhattan (city block) distance
-// between two points (represented as vectors).
-func ManhattanDistance(a, b wyvern.Vector[float64]) float64 {
-	var distance float64
-	for _, component := range a.Difference(b) {
-		// Remember that we want the magnitude of the difference
-		distance += math.Abs(component)
-	}
-
-	return distance
-}
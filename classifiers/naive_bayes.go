@@ -0,0 +1,283 @@
+package classifiers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ScarletTanager/wyvern"
+)
+
+const (
+	ClassifierType_NaiveBayes string = "Gaussian Naive Bayes Classifier"
+
+	// DEFAULT_VARIANCE_SMOOTHING is the share of the largest attribute variance added to
+	// every per-class variance, so that attributes with (near) constant values within a
+	// class do not produce infinite densities.
+	DEFAULT_VARIANCE_SMOOTHING = 1e-9
+)
+
+// NaiveBayesClassifier is a Gaussian naive Bayes model: each attribute is assumed to be
+// normally distributed within each class, independently of the other attributes.
+type NaiveBayesClassifier struct {
+	ClassifierImplementation
+	Configuration NaiveBayesClassifierConfig
+
+	// Fitted parameters - priors are indexed by class, means and variances by class
+	// and then by attribute.
+	priors           []float64
+	means, variances [][]float64
+}
+
+type NaiveBayesClassifierConfig struct {
+	VarianceSmoothing float64
+}
+
+func NewNaiveBayes(varianceSmoothing float64) (*NaiveBayesClassifier, error) {
+	if varianceSmoothing < 0 {
+		return nil, errors.New("Unable to create classifier, variance smoothing cannot be negative")
+	}
+
+	if varianceSmoothing == 0 {
+		varianceSmoothing = DEFAULT_VARIANCE_SMOOTHING
+	}
+
+	return &NaiveBayesClassifier{
+		Configuration: NaiveBayesClassifierConfig{VarianceSmoothing: varianceSmoothing},
+	}, nil
+}
+
+func (nbc *NaiveBayesClassifier) Config() interface{} {
+	return nbc.Configuration
+}
+
+func (nbc *NaiveBayesClassifier) Type() string {
+	return ClassifierType_NaiveBayes
+}
+
+func (nbc *NaiveBayesClassifier) TrainFromCSV(data []byte, cfg *DataSplitConfig) error {
+	ds, err := FromCSV(data)
+	if err != nil {
+		return fmt.Errorf("Error training from CSV: %w", err)
+	}
+
+	return nbc.train(ds, cfg)
+}
+
+func (nbc *NaiveBayesClassifier) TrainFromCSVFile(path string, cfg *DataSplitConfig) error {
+	ds, err := FromCSVFile(path)
+	if err != nil {
+		return fmt.Errorf("Error training from CSV file %s: %w", path, err)
+	}
+
+	return nbc.train(ds, cfg)
+}
+
+func (nbc *NaiveBayesClassifier) TrainFromDataset(ds *DataSet, cfg *DataSplitConfig) error {
+	return nbc.train(ds, cfg)
+}
+
+func (nbc *NaiveBayesClassifier) TrainFromJSON(data []byte, cfg *DataSplitConfig) error {
+	ds, err := FromJSON(data)
+	if err != nil {
+		return fmt.Errorf("Error training from JSON: %w", err)
+	}
+
+	return nbc.train(ds, cfg)
+}
+
+func (nbc *NaiveBayesClassifier) TrainFromJSONFile(path string, cfg *DataSplitConfig) error {
+	ds, err := FromJSONFile(path)
+	if err != nil {
+		return fmt.Errorf("Error training from JSON file: %w", err)
+	}
+
+	return nbc.train(ds, cfg)
+}
+
+func (nbc *NaiveBayesClassifier) Retrain(cfg *DataSplitConfig) error {
+	return nbc.train(nbc.RawData, cfg)
+}
+
+// train splits the data and fits a new model to the training data, replacing this one only
+// if training succeeds
+func (nbc *NaiveBayesClassifier) train(ds *DataSet, cfg *DataSplitConfig) error {
+	trained := &NaiveBayesClassifier{Configuration: nbc.Configuration}
+	if err := trained.split(ds, cfg); err != nil {
+		return err
+	}
+
+	trained.fit()
+	*nbc = *trained
+	return nil
+}
+
+// fit fits the class priors and the per-class, per-attribute means and variances to the
+// training data
+func (nbc *NaiveBayesClassifier) fit() {
+
+	classCount := len(nbc.TrainingData.ClassNames)
+	attributeCount := len(nbc.TrainingData.AttributeNames)
+
+	counts := make([]int, classCount)
+	nbc.priors = make([]float64, classCount)
+	nbc.means = make([][]float64, classCount)
+	nbc.variances = make([][]float64, classCount)
+	for ci := range nbc.means {
+		nbc.means[ci] = make([]float64, attributeCount)
+		nbc.variances[ci] = make([]float64, attributeCount)
+	}
+
+	for _, r := range nbc.TrainingData.Records {
+		counts[r.Class]++
+		for ai, v := range r.AttributeValues {
+			nbc.means[r.Class][ai] += v
+		}
+	}
+
+	for ci, count := range counts {
+		if count == 0 {
+			continue
+		}
+		nbc.priors[ci] = float64(count) / float64(len(nbc.TrainingData.Records))
+		for ai := range nbc.means[ci] {
+			nbc.means[ci][ai] /= float64(count)
+		}
+	}
+
+	for _, r := range nbc.TrainingData.Records {
+		for ai, v := range r.AttributeValues {
+			d := v - nbc.means[r.Class][ai]
+			nbc.variances[r.Class][ai] += d * d
+		}
+	}
+
+	// Smooth the variances in proportion to the largest overall attribute variance
+	epsilon := nbc.Configuration.VarianceSmoothing * maxAttributeVariance(nbc.TrainingData.Records, attributeCount)
+	if epsilon == 0 {
+		epsilon = nbc.Configuration.VarianceSmoothing
+	}
+
+	for ci, count := range counts {
+		for ai := range nbc.variances[ci] {
+			if count > 0 {
+				nbc.variances[ci][ai] /= float64(count)
+			}
+			nbc.variances[ci][ai] += epsilon
+		}
+	}
+}
+
+func maxAttributeVariance(records []Record, attributeCount int) float64 {
+	var maxVariance float64
+	for ai := 0; ai < attributeCount; ai++ {
+		var sum, sumSquares float64
+		for _, r := range records {
+			sum += r.AttributeValues[ai]
+			sumSquares += r.AttributeValues[ai] * r.AttributeValues[ai]
+		}
+
+		mean := sum / float64(len(records))
+		if variance := (sumSquares / float64(len(records))) - (mean * mean); variance > maxVariance {
+			maxVariance = variance
+		}
+	}
+
+	return maxVariance
+}
+
+// Test classifies the records of the testing data
+func (nbc *NaiveBayesClassifier) Test() (TestResults, error) {
+	return nbc.TestContext(context.Background())
+}
+
+// TestContext classifies the records of the testing data, stopping early if the context
+// is cancelled
+func (nbc *NaiveBayesClassifier) TestContext(ctx context.Context) (TestResults, error) {
+	if nbc.TrainingData == nil || nbc.TestingData == nil {
+		return nil, errors.New("Untestable model")
+	}
+
+	results, err := classifyAll(ctx, nbc.TestingData.Records, 1, func(r Record, _ int) TestResult {
+		return nbc.classifyRecord(r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	nbc.Results = results
+	return results, nil
+}
+
+// Predict classifies a single unlabeled record, represented by its attribute values.
+// The values must be in the same order as the AttributeNames of the training data.
+func (nbc *NaiveBayesClassifier) Predict(values wyvern.Vector[float64]) (Prediction, error) {
+	if err := nbc.checkValues(values); err != nil {
+		return Prediction{}, err
+	}
+
+	return nbc.prediction(nbc.classifyRecord(Record{Class: NO_PREDICTION, AttributeValues: values})), nil
+}
+
+// PredictBatch classifies each of the unlabeled records in the batch.  If any member
+// of the batch is invalid, no predictions are made.
+func (nbc *NaiveBayesClassifier) PredictBatch(batch []wyvern.Vector[float64]) ([]Prediction, error) {
+	if err := nbc.checkBatch(batch); err != nil {
+		return nil, err
+	}
+
+	predictions := make([]Prediction, len(batch))
+	for i, values := range batch {
+		predictions[i] = nbc.prediction(nbc.classifyRecord(Record{Class: NO_PREDICTION, AttributeValues: values}))
+	}
+
+	return predictions, nil
+}
+
+// classifyRecord computes the posterior probability of each class and predicts the
+// most probable one.  The computation is done with log likelihoods to avoid underflow.
+func (nbc *NaiveBayesClassifier) classifyRecord(r Record) TestResult {
+	result := TestResult{
+		Record:        r,
+		Predicted:     NO_PREDICTION,
+		Probabilities: make(map[string]float64, len(nbc.TrainingData.ClassNames)),
+	}
+
+	logPosteriors := make([]float64, len(nbc.priors))
+	maxLogPosterior := math.Inf(-1)
+	for ci, prior := range nbc.priors {
+		if prior == 0 {
+			logPosteriors[ci] = math.Inf(-1)
+			continue
+		}
+
+		logPosteriors[ci] = math.Log(prior)
+		for ai, v := range r.AttributeValues {
+			d := v - nbc.means[ci][ai]
+			logPosteriors[ci] -= 0.5 * (math.Log(2*math.Pi*nbc.variances[ci][ai]) + (d*d)/nbc.variances[ci][ai])
+		}
+
+		if logPosteriors[ci] > maxLogPosterior {
+			maxLogPosterior = logPosteriors[ci]
+			result.Predicted = ci
+		}
+	}
+
+	// Normalize (shifting by the maximum keeps the exponentials in range)
+	var total float64
+	for ci, lp := range logPosteriors {
+		logPosteriors[ci] = math.Exp(lp - maxLogPosterior)
+		total += logPosteriors[ci]
+	}
+
+	for ci, className := range nbc.TrainingData.ClassNames {
+		result.Probabilities[className] = logPosteriors[ci] / total
+	}
+
+	if result.Predicted != NO_PREDICTION {
+		result.Probability = result.Probabilities[nbc.TrainingData.ClassNames[result.Predicted]]
+	}
+
+	return result
+}
@@ -0,0 +1,167 @@
+package classifiers_test
+
+import (
+	"github.com/ScarletTanager/basilisk/classifiers"
+	"github.com/ScarletTanager/wyvern"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NaiveBayes", func() {
+	var (
+		nbc               *classifiers.NaiveBayesClassifier
+		path              string
+		cfg               *classifiers.DataSplitConfig
+		varianceSmoothing float64
+	)
+
+	BeforeEach(func() {
+		varianceSmoothing = 0
+		cfg = nil
+	})
+
+	JustBeforeEach(func() {
+		nbc, _ = classifiers.NewNaiveBayes(varianceSmoothing)
+	})
+
+	Describe("New", func() {
+		It("Returns a new classifier with the default variance smoothing", func() {
+			c, err := classifiers.NewNaiveBayes(varianceSmoothing)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Configuration.VarianceSmoothing).To(Equal(classifiers.DEFAULT_VARIANCE_SMOOTHING))
+		})
+
+		When("Called with a negative variance smoothing", func() {
+			BeforeEach(func() {
+				varianceSmoothing = -1.0
+			})
+
+			It("Returns nil and an error", func() {
+				c, err := classifiers.NewNaiveBayes(varianceSmoothing)
+				Expect(err).To(HaveOccurred())
+				Expect(c).To(BeNil())
+			})
+		})
+	})
+
+	Describe("Train", func() {
+		var single *classifiers.DataSet
+
+		BeforeEach(func() {
+			path = "../fixtures/students.csv"
+
+			ds, err := classifiers.FromCSVFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			// Too few records for the split to leave any for training
+			single, err = classifiers.NewDataSet(ds.ClassNames, ds.AttributeNames, ds.Records[:1])
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		When("The data leaves no training records", func() {
+			It("Returns an error and leaves the model untrained", func() {
+				Expect(nbc.TrainFromDataset(single, cfg)).To(MatchError(ContainSubstring("no training records")))
+				Expect(nbc.RawData).To(BeNil())
+				Expect(nbc.TrainingData).To(BeNil())
+				_, err := nbc.Test()
+				Expect(err).To(HaveOccurred())
+			})
+
+			When("The model has already been trained", func() {
+				JustBeforeEach(func() {
+					Expect(nbc.TrainFromCSVFile(path, cfg)).To(Succeed())
+				})
+
+				It("Returns an error and leaves the model unchanged", func() {
+					trainingData := nbc.TrainingData
+					before, err := nbc.Test()
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(nbc.TrainFromDataset(single, cfg)).To(MatchError(ContainSubstring("no training records")))
+					Expect(nbc.TrainingData).To(BeIdenticalTo(trainingData))
+					after, err := nbc.Test()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(after).To(Equal(before))
+				})
+			})
+		})
+	})
+
+	Describe("Test", func() {
+		BeforeEach(func() {
+			path = "../fixtures/students.csv"
+			cfg = &classifiers.DataSplitConfig{
+				Method: classifiers.SplitSequential,
+			}
+		})
+
+		When("The model has been trained", func() {
+			JustBeforeEach(func() {
+				Expect(nbc.TrainFromCSVFile(path, cfg)).NotTo(HaveOccurred())
+			})
+
+			It("Classifies the testing records", func() {
+				results, err := nbc.Test()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(results).To(HaveLen(len(nbc.TestingData.Records)))
+				Expect(results.Analyze().Accuracy).To(Equal(1.0))
+			})
+
+			It("Reports the posterior probability of every class", func() {
+				results, _ := nbc.Test()
+				for _, res := range results {
+					Expect(res.Probabilities).To(HaveLen(len(nbc.TrainingData.ClassNames)))
+					total := 0.0
+					for _, p := range res.Probabilities {
+						total += p
+					}
+					Expect(total).To(BeNumerically("~", 1.0))
+					Expect(res.Probability).To(Equal(res.Probabilities[nbc.TrainingData.ClassNames[res.Predicted]]))
+				}
+			})
+
+			When("The data has several attributes", func() {
+				BeforeEach(func() {
+					// The classes are interleaved, so a sequential split is representative
+					path = "../fixtures/iris_interleaved.csv"
+				})
+
+				It("Classifies most of the testing records correctly", func() {
+					results, err := nbc.Test()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(results.Analyze().Accuracy).To(BeNumerically(">", 0.85))
+				})
+			})
+		})
+
+		When("The model has not been trained", func() {
+			It("Returns an error", func() {
+				_, err := nbc.Test()
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("Predict", func() {
+		BeforeEach(func() {
+			path = "../fixtures/students.csv"
+			cfg = &classifiers.DataSplitConfig{
+				Method: classifiers.SplitSequential,
+			}
+		})
+
+		JustBeforeEach(func() {
+			Expect(nbc.TrainFromCSVFile(path, cfg)).NotTo(HaveOccurred())
+		})
+
+		It("Predicts the class of the record", func() {
+			p, err := nbc.Predict(wyvern.Vector[float64]{13.2})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.ClassName).To(Equal("Middle"))
+		})
+
+		It("Rejects records with the wrong number of attribute values", func() {
+			_, err := nbc.Predict(wyvern.Vector[float64]{13.2, 1})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,407 @@
+package classifiers
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/ScarletTanager/wyvern"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// Compute the distance to every training record
+	IndexMethod_BruteForce = "brute_force"
+	// Exact search using a KD-tree - only valid for the Lp distance methods (euclidean, manhattan,
+	// minkowski and chebyshev)
+	IndexMethod_KDTree = "kd_tree"
+	// Exact search using a ball tree - valid for any distance method satisfying the triangle inequality
+	IndexMethod_BallTree = "ball_tree"
+	// IndexMethod_HNSW (approximate search) is defined alongside its implementation
+
+	// indexLeafSize is the largest number of points stored in a leaf of a tree index
+	indexLeafSize = 16
+)
+
+// neighborIndex finds the training records nearest to a query
+type neighborIndex interface {
+	// nearest returns (up to) the k nearest points to the query, ordered by distance and
+	// then by index, so that results do not depend on the index used
+	nearest(query wyvern.Vector[float64], k int) []Neighbor
+	// insert adds a point to the index, which is given the next training record index
+	insert(point wyvern.Vector[float64], class int)
+}
+
+// neighborBefore orders neighbors by distance, breaking ties by training record index
+func neighborBefore(a, b Neighbor) bool {
+	if a.Distance == b.Distance {
+		return a.Index < b.Index
+	}
+	return a.Distance < b.Distance
+}
+
+// neighborHeap is a max-heap (the farthest neighbor is at the root) used to keep the
+// best k candidates found so far
+type neighborHeap []Neighbor
+
+func (h neighborHeap) Len() int           { return len(h) }
+func (h neighborHeap) Less(i, j int) bool { return neighborBefore(h[j], h[i]) }
+func (h neighborHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *neighborHeap) Push(x any)        { *h = append(*h, x.(Neighbor)) }
+func (h *neighborHeap) Pop() any {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
+
+// offer adds the candidate if fewer than k neighbors have been found, or if it is
+// nearer than the farthest of them
+func (h *neighborHeap) offer(candidate Neighbor, k int) {
+	if k <= 0 {
+		return
+	}
+
+	if len(*h) < k {
+		// Append and sift up directly rather than via heap.Push, which boxes the candidate
+		*h = append(*h, candidate)
+		heap.Fix(h, len(*h)-1)
+	} else if neighborBefore(candidate, (*h)[0]) {
+		(*h)[0] = candidate
+		heap.Fix(h, 0)
+	}
+}
+
+// bound returns the distance a candidate must not exceed to be offered
+func (h neighborHeap) bound(k int) float64 {
+	if len(h) < k {
+		return math.Inf(1)
+	}
+	return h[0].Distance
+}
+
+// sorted empties the heap into a slice, nearest first
+func (h *neighborHeap) sorted() []Neighbor {
+	neighbors := make([]Neighbor, len(*h))
+	for i := len(neighbors) - 1; i >= 0; i-- {
+		neighbors[i] = (*h)[0]
+		(*h)[0] = (*h)[i]
+		*h = (*h)[:i]
+		if i > 0 {
+			heap.Fix(h, 0)
+		}
+	}
+	return neighbors
+}
+
+// neighborHeaps holds emptied heaps for reuse, so that searching for the neighbors of
+// each test record does not allocate a new heap
+var neighborHeaps = sync.Pool{
+	New: func() any {
+		return new(neighborHeap)
+	},
+}
+
+// acquireNeighborHeap returns an empty heap with room for k neighbors
+func acquireNeighborHeap(k int) *neighborHeap {
+	h := neighborHeaps.Get().(*neighborHeap)
+	if cap(*h) < k {
+		*h = make(neighborHeap, 0, k)
+	}
+	*h = (*h)[:0]
+	return h
+}
+
+// releaseNeighborHeap returns the heap to the pool - the heap must not be used afterwards
+func releaseNeighborHeap(h *neighborHeap) {
+	neighborHeaps.Put(h)
+}
+
+// nearestNeighbors searches for the k nearest neighbors using a pooled heap, returning
+// them nearest first
+func nearestNeighbors(k int, search func(h *neighborHeap)) []Neighbor {
+	if k <= 0 {
+		return nil
+	}
+
+	h := acquireNeighborHeap(k)
+	defer releaseNeighborHeap(h)
+
+	search(h)
+	return h.sorted()
+}
+
+// indexedPoints holds the attribute values and classes of the training records being indexed
+type indexedPoints struct {
+	points   []wyvern.Vector[float64]
+	classes  []int
+	distance DistanceFunction
+}
+
+func (ip *indexedPoints) neighbor(query wyvern.Vector[float64], i int) Neighbor {
+	return Neighbor{
+		Index:    i,
+		Class:    ip.classes[i],
+		Distance: ip.distance(query, ip.points[i]),
+	}
+}
+
+type bruteForceIndex struct {
+	indexedPoints
+}
+
+// nearest computes the distance to every point, keeping only the best k candidates
+func (bf *bruteForceIndex) nearest(query wyvern.Vector[float64], k int) []Neighbor {
+	return nearestNeighbors(k, func(h *neighborHeap) {
+		for i, p := range bf.points {
+			// Points are visited in index order, so a point at the same distance as the
+			// farthest candidate never displaces it
+			if d := bf.distance(query, p); len(*h) < k || d < (*h)[0].Distance {
+				h.offer(Neighbor{Index: i, Class: bf.classes[i], Distance: d}, k)
+			}
+		}
+	})
+}
+
+func (bf *bruteForceIndex) insert(point wyvern.Vector[float64], class int) {
+	bf.points = append(bf.points, point)
+	bf.classes = append(bf.classes, class)
+}
+
+// kdNode is a node of a KD-tree.  Leaves hold point indices, interior nodes split their
+// points on one axis, with points <= the split value to the left.
+type kdNode struct {
+	axis        int
+	split       float64
+	left, right *kdNode
+	indices     []int
+}
+
+type kdTreeIndex struct {
+	indexedPoints
+	root *kdNode
+}
+
+func newKDTreeIndex(ip indexedPoints) *kdTreeIndex {
+	kd := &kdTreeIndex{indexedPoints: ip}
+	kd.root = kd.build(allIndices(len(ip.points)))
+	return kd
+}
+
+func (kd *kdTreeIndex) build(indices []int) *kdNode {
+	// Leaves are clipped, so that inserting into one cannot overwrite its neighbor's indices
+	if len(indices) <= indexLeafSize {
+		return &kdNode{indices: slices.Clip(indices)}
+	}
+
+	// Split on the axis with the widest spread, at the median
+	axis := widestAxis(kd.points, indices)
+	sort.Slice(indices, func(i, j int) bool {
+		return kd.points[indices[i]][axis] < kd.points[indices[j]][axis]
+	})
+	median := len(indices) / 2
+
+	// Building the children re-sorts their parts of indices, so the split value has to
+	// be read first
+	node := &kdNode{
+		axis:  axis,
+		split: kd.points[indices[median-1]][axis],
+	}
+	node.left = kd.build(indices[:median])
+	node.right = kd.build(indices[median:])
+	return node
+}
+
+// insert adds the point to the leaf it falls in, splitting the leaf if it grows too large.
+// The split values of the interior nodes are left alone, so the tree may become unbalanced
+// if many points are inserted.
+func (kd *kdTreeIndex) insert(point wyvern.Vector[float64], class int) {
+	kd.points = append(kd.points, point)
+	kd.classes = append(kd.classes, class)
+
+	node := kd.root
+	for node.indices == nil {
+		if point[node.axis] > node.split {
+			node = node.right
+		} else {
+			node = node.left
+		}
+	}
+
+	node.indices = append(node.indices, len(kd.points)-1)
+	if len(node.indices) > indexLeafSize {
+		*node = *kd.build(node.indices)
+	}
+}
+
+func (kd *kdTreeIndex) nearest(query wyvern.Vector[float64], k int) []Neighbor {
+	return nearestNeighbors(k, func(h *neighborHeap) {
+		kd.search(kd.root, query, k, h)
+	})
+}
+
+func (kd *kdTreeIndex) search(node *kdNode, query wyvern.Vector[float64], k int, h *neighborHeap) {
+	if node.indices != nil {
+		for _, i := range node.indices {
+			h.offer(kd.neighbor(query, i), k)
+		}
+		return
+	}
+
+	near, far := node.left, node.right
+	if query[node.axis] > node.split {
+		near, far = far, near
+	}
+
+	kd.search(near, query, k, h)
+
+	// The distance along a single axis is a lower bound on any Lp distance, so the far
+	// side can only hold candidates if the splitting plane is within the current bound.
+	// Points at exactly the bound may still win on index, hence <= rather than <.
+	if math.Abs(query[node.axis]-node.split) <= h.bound(k) {
+		kd.search(far, query, k, h)
+	}
+}
+
+// ballNode is a node of a ball tree - every point beneath the node lies within radius
+// of the center
+type ballNode struct {
+	center      wyvern.Vector[float64]
+	radius      float64
+	left, right *ballNode
+	indices     []int
+}
+
+type ballTreeIndex struct {
+	indexedPoints
+	root *ballNode
+}
+
+func newBallTreeIndex(ip indexedPoints) *ballTreeIndex {
+	bt := &ballTreeIndex{indexedPoints: ip}
+	bt.root = bt.build(allIndices(len(ip.points)))
+	return bt
+}
+
+func (bt *ballTreeIndex) build(indices []int) *ballNode {
+	node := &ballNode{center: centroid(bt.points, indices)}
+	for _, i := range indices {
+		node.radius = math.Max(node.radius, bt.distance(node.center, bt.points[i]))
+	}
+
+	if len(indices) <= indexLeafSize {
+		node.indices = slices.Clip(indices)
+		return node
+	}
+
+	axis := widestAxis(bt.points, indices)
+	sort.Slice(indices, func(i, j int) bool {
+		return bt.points[indices[i]][axis] < bt.points[indices[j]][axis]
+	})
+	median := len(indices) / 2
+
+	node.left = bt.build(indices[:median])
+	node.right = bt.build(indices[median:])
+	return node
+}
+
+// insert adds the point to the leaf with the nearest center, growing the balls along the way
+// so that they still contain all of their points, and splits the leaf if it grows too large.
+func (bt *ballTreeIndex) insert(point wyvern.Vector[float64], class int) {
+	bt.points = append(bt.points, point)
+	bt.classes = append(bt.classes, class)
+
+	node := bt.root
+	for {
+		node.radius = math.Max(node.radius, bt.distance(node.center, point))
+		if node.indices != nil {
+			break
+		}
+
+		if bt.distance(point, node.right.center) < bt.distance(point, node.left.center) {
+			node = node.right
+		} else {
+			node = node.left
+		}
+	}
+
+	node.indices = append(node.indices, len(bt.points)-1)
+	if len(node.indices) > indexLeafSize {
+		*node = *bt.build(node.indices)
+	}
+}
+
+func (bt *ballTreeIndex) nearest(query wyvern.Vector[float64], k int) []Neighbor {
+	return nearestNeighbors(k, func(h *neighborHeap) {
+		bt.search(bt.root, query, k, h)
+	})
+}
+
+func (bt *ballTreeIndex) search(node *ballNode, query wyvern.Vector[float64], k int, h *neighborHeap) {
+	// By the triangle inequality, no point in the ball is nearer than this
+	if bt.distance(query, node.center)-node.radius > h.bound(k) {
+		return
+	}
+
+	if node.indices != nil {
+		for _, i := range node.indices {
+			h.offer(bt.neighbor(query, i), k)
+		}
+		return
+	}
+
+	// Visit the nearer child first, to tighten the bound sooner
+	near, far := node.left, node.right
+	if bt.distance(query, far.center) < bt.distance(query, near.center) {
+		near, far = far, near
+	}
+
+	bt.search(near, query, k, h)
+	bt.search(far, query, k, h)
+}
+
+func allIndices(n int) []int {
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = i
+	}
+	return indices
+}
+
+// widestAxis returns the attribute with the greatest range of values among the points
+func widestAxis(points []wyvern.Vector[float64], indices []int) int {
+	var (
+		axis   int
+		spread float64
+	)
+
+	for a := range points[indices[0]] {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, i := range indices {
+			lo = math.Min(lo, points[i][a])
+			hi = math.Max(hi, points[i][a])
+		}
+
+		if hi-lo > spread {
+			axis, spread = a, hi-lo
+		}
+	}
+
+	return axis
+}
+
+func centroid(points []wyvern.Vector[float64], indices []int) wyvern.Vector[float64] {
+	center := make(wyvern.Vector[float64], len(points[indices[0]]))
+	for _, i := range indices {
+		for a, v := range points[i] {
+			center[a] += v
+		}
+	}
+
+	for a := range center {
+		center[a] /= float64(len(indices))
+	}
+
+	return center
+}
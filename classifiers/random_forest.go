@@ -0,0 +1,299 @@
+package classifiers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/ScarletTanager/wyvern"
+)
+
+const (
+	ClassifierType_RandomForest string = "Random Forest Classifier"
+
+	DEFAULT_FOREST_SIZE = 100
+)
+
+// RandomForestClassifier is an ensemble of decision trees, each grown on a bootstrap sample
+// of the training records and considering a random subset of the attributes at each split.
+type RandomForestClassifier struct {
+	ClassifierImplementation
+	Configuration RandomForestClassifierConfig
+
+	trees            []*DecisionTreeNode
+	importances      []float64
+	outOfBagAccuracy float64
+}
+
+type RandomForestClassifierConfig struct {
+	DecisionTreeClassifierConfig
+	// Trees is the number of trees in the forest - 0 means DEFAULT_FOREST_SIZE
+	Trees int
+	// MaxFeatures is the number of attributes considered at each split - 0 means the
+	// square root of the number of attributes
+	MaxFeatures int
+	// Seed seeds the bootstrap sampling and attribute selection - 0 means a random seed
+	Seed int64
+}
+
+func NewRandomForest(cfg RandomForestClassifierConfig) (*RandomForestClassifier, error) {
+	if err := cfg.DecisionTreeClassifierConfig.validate(); err != nil {
+		return nil, fmt.Errorf("Unable to create classifier: %w", err)
+	}
+
+	if cfg.Trees < 0 {
+		return nil, errors.New("Unable to create classifier, the number of trees cannot be negative")
+	}
+
+	if cfg.MaxFeatures < 0 {
+		return nil, errors.New("Unable to create classifier, the number of features per split cannot be negative")
+	}
+
+	if cfg.Trees == 0 {
+		cfg.Trees = DEFAULT_FOREST_SIZE
+	}
+
+	if cfg.Criterion == "" {
+		cfg.Criterion = SplitCriterion_Gini
+	}
+
+	if cfg.MinSamplesLeaf == 0 {
+		cfg.MinSamplesLeaf = 1
+	}
+
+	return &RandomForestClassifier{
+		Configuration: cfg,
+	}, nil
+}
+
+func (rfc *RandomForestClassifier) Config() interface{} {
+	return rfc.Configuration
+}
+
+func (rfc *RandomForestClassifier) Type() string {
+	return ClassifierType_RandomForest
+}
+
+// OutOfBagAccuracy returns the accuracy over the training records of the predictions made
+// by the trees whose bootstrap samples did not include each record.
+func (rfc *RandomForestClassifier) OutOfBagAccuracy() float64 {
+	return rfc.outOfBagAccuracy
+}
+
+// AttributeImportances returns the mean (over the trees) share of the impurity decrease
+// contributed by splits on each attribute, keyed by attribute name.
+func (rfc *RandomForestClassifier) AttributeImportances() map[string]float64 {
+	if rfc.TrainingData == nil {
+		return nil
+	}
+
+	return attributeImportances(rfc.importances, rfc.TrainingData.AttributeNames)
+}
+
+func (rfc *RandomForestClassifier) TrainFromCSV(data []byte, cfg *DataSplitConfig) error {
+	ds, err := FromCSV(data)
+	if err != nil {
+		return fmt.Errorf("Error training from CSV: %w", err)
+	}
+
+	return rfc.train(ds, cfg)
+}
+
+func (rfc *RandomForestClassifier) TrainFromCSVFile(path string, cfg *DataSplitConfig) error {
+	ds, err := FromCSVFile(path)
+	if err != nil {
+		return fmt.Errorf("Error training from CSV file %s: %w", path, err)
+	}
+
+	return rfc.train(ds, cfg)
+}
+
+func (rfc *RandomForestClassifier) TrainFromDataset(ds *DataSet, cfg *DataSplitConfig) error {
+	return rfc.train(ds, cfg)
+}
+
+func (rfc *RandomForestClassifier) TrainFromJSON(data []byte, cfg *DataSplitConfig) error {
+	ds, err := FromJSON(data)
+	if err != nil {
+		return fmt.Errorf("Error training from JSON: %w", err)
+	}
+
+	return rfc.train(ds, cfg)
+}
+
+func (rfc *RandomForestClassifier) TrainFromJSONFile(path string, cfg *DataSplitConfig) error {
+	ds, err := FromJSONFile(path)
+	if err != nil {
+		return fmt.Errorf("Error training from JSON file: %w", err)
+	}
+
+	return rfc.train(ds, cfg)
+}
+
+func (rfc *RandomForestClassifier) Retrain(cfg *DataSplitConfig) error {
+	return rfc.train(rfc.RawData, cfg)
+}
+
+// train splits the data and fits a new model to the training data, replacing this one only
+// if training succeeds
+func (rfc *RandomForestClassifier) train(ds *DataSet, cfg *DataSplitConfig) error {
+	trained := &RandomForestClassifier{Configuration: rfc.Configuration}
+	if err := trained.split(ds, cfg); err != nil {
+		return err
+	}
+
+	trained.fit()
+	*rfc = *trained
+	return nil
+}
+
+// fit grows each tree on a bootstrap sample (drawn with replacement) of the training records,
+// and scores each training record using only the trees which did not see it.
+func (rfc *RandomForestClassifier) fit() {
+	recordCount := len(rfc.TrainingData.Records)
+	seed := rfc.Configuration.Seed
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	maxFeatures := rfc.Configuration.MaxFeatures
+	if maxFeatures == 0 {
+		maxFeatures = int(math.Max(1, math.Round(math.Sqrt(float64(len(rfc.TrainingData.AttributeNames))))))
+	}
+
+	rfc.trees = make([]*DecisionTreeNode, rfc.Configuration.Trees)
+	rfc.importances = make([]float64, len(rfc.TrainingData.AttributeNames))
+
+	// oobVotes is indexed by training record, then by class
+	oobVotes := make([][]int, recordCount)
+	for i := range oobVotes {
+		oobVotes[i] = make([]int, len(rfc.TrainingData.ClassNames))
+	}
+
+	sample := make([]int, recordCount)
+	inBag := make([]bool, recordCount)
+	for ti := range rfc.trees {
+		for i := range inBag {
+			inBag[i] = false
+		}
+
+		for i := range sample {
+			sample[i] = rng.Intn(recordCount)
+			inBag[sample[i]] = true
+		}
+
+		tb := newTreeBuilder(rfc.TrainingData, rfc.Configuration.DecisionTreeClassifierConfig, maxFeatures, rand.New(rand.NewSource(rng.Int63())))
+		rfc.trees[ti] = tb.build(sample, 0)
+
+		// Each tree contributes equally to the importances
+		for ai, importance := range normalizeImportances(tb.importances) {
+			rfc.importances[ai] += importance / float64(len(rfc.trees))
+		}
+
+		for ri, r := range rfc.TrainingData.Records {
+			if !inBag[ri] {
+				oobVotes[ri][rfc.trees[ti].leafFor(r.AttributeValues).Class]++
+			}
+		}
+	}
+
+	var scored, correct int
+	for ri, votes := range oobVotes {
+		predicted, total := NO_PREDICTION, 0
+		for ci, v := range votes {
+			total += v
+			if v > 0 && (predicted == NO_PREDICTION || v > votes[predicted]) {
+				predicted = ci
+			}
+		}
+
+		if total > 0 {
+			scored++
+			if predicted == rfc.TrainingData.Records[ri].Class {
+				correct++
+			}
+		}
+	}
+
+	rfc.outOfBagAccuracy = 0
+	if scored > 0 {
+		rfc.outOfBagAccuracy = float64(correct) / float64(scored)
+	}
+}
+
+// Test classifies the records of the testing data
+func (rfc *RandomForestClassifier) Test() (TestResults, error) {
+	return rfc.TestContext(context.Background())
+}
+
+// TestContext classifies the records of the testing data, stopping early if the context
+// is cancelled
+func (rfc *RandomForestClassifier) TestContext(ctx context.Context) (TestResults, error) {
+	if rfc.TrainingData == nil || rfc.TestingData == nil {
+		return nil, errors.New("Untestable model")
+	}
+
+	results, err := classifyAll(ctx, rfc.TestingData.Records, 1, func(r Record, _ int) TestResult {
+		return rfc.classifyRecord(r)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rfc.Results = results
+	return results, nil
+}
+
+// Predict classifies a single unlabeled record, represented by its attribute values.
+// The values must be in the same order as the AttributeNames of the training data.
+func (rfc *RandomForestClassifier) Predict(values wyvern.Vector[float64]) (Prediction, error) {
+	if err := rfc.checkValues(values); err != nil {
+		return Prediction{}, err
+	}
+
+	return rfc.prediction(rfc.classifyRecord(Record{Class: NO_PREDICTION, AttributeValues: values})), nil
+}
+
+// PredictBatch classifies each of the unlabeled records in the batch.  If any member
+// of the batch is invalid, no predictions are made.
+func (rfc *RandomForestClassifier) PredictBatch(batch []wyvern.Vector[float64]) ([]Prediction, error) {
+	if err := rfc.checkBatch(batch); err != nil {
+		return nil, err
+	}
+
+	predictions := make([]Prediction, len(batch))
+	for i, values := range batch {
+		predictions[i] = rfc.prediction(rfc.classifyRecord(Record{Class: NO_PREDICTION, AttributeValues: values}))
+	}
+
+	return predictions, nil
+}
+
+// classifyRecord has each tree vote for the majority class of the leaf the record falls into
+func (rfc *RandomForestClassifier) classifyRecord(r Record) TestResult {
+	result := TestResult{
+		Record:        r,
+		Predicted:     NO_PREDICTION,
+		Probabilities: make(map[string]float64, len(rfc.TrainingData.ClassNames)),
+	}
+
+	votes := make([]int, len(rfc.TrainingData.ClassNames))
+	for _, tree := range rfc.trees {
+		votes[tree.leafFor(r.AttributeValues).Class]++
+	}
+
+	for ci, className := range rfc.TrainingData.ClassNames {
+		result.Probabilities[className] = float64(votes[ci]) / float64(len(rfc.trees))
+		if result.Predicted == NO_PREDICTION || votes[ci] > votes[result.Predicted] {
+			result.Predicted = ci
+		}
+	}
+
+	result.Votes = votes[result.Predicted]
+	result.Probability = result.Probabilities[rfc.TrainingData.ClassNames[result.Predicted]]
+
+	return result
+}
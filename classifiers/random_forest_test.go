@@ -0,0 +1,180 @@
+package classifiers_test
+
+import (
+	"github.com/ScarletTanager/basilisk/classifiers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RandomForest", func() {
+	var (
+		rfc       *classifiers.RandomForestClassifier
+		path      string
+		cfg       *classifiers.DataSplitConfig
+		forestCfg classifiers.RandomForestClassifierConfig
+	)
+
+	BeforeEach(func() {
+		forestCfg = classifiers.RandomForestClassifierConfig{
+			Trees: 25,
+			Seed:  42,
+		}
+		path = "../datasets/iris.csv"
+		cfg = &classifiers.DataSplitConfig{
+			Method: classifiers.SplitSequential,
+			// Sequential splits of the iris data leave the last class out of
+			// the training data unless almost everything is used for training
+			TrainingShare: .9,
+		}
+	})
+
+	JustBeforeEach(func() {
+		rfc, _ = classifiers.NewRandomForest(forestCfg)
+	})
+
+	Describe("New", func() {
+		It("Applies the defaults", func() {
+			c, err := classifiers.NewRandomForest(classifiers.RandomForestClassifierConfig{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Configuration.Trees).To(Equal(classifiers.DEFAULT_FOREST_SIZE))
+			Expect(c.Configuration.Criterion).To(Equal(classifiers.SplitCriterion_Gini))
+		})
+
+		When("The number of trees is negative", func() {
+			BeforeEach(func() {
+				forestCfg.Trees = -5
+			})
+
+			It("Returns nil and an error", func() {
+				c, err := classifiers.NewRandomForest(forestCfg)
+				Expect(err).To(HaveOccurred())
+				Expect(c).To(BeNil())
+			})
+		})
+
+		When("The tree configuration is invalid", func() {
+			BeforeEach(func() {
+				forestCfg.Criterion = "chaos"
+			})
+
+			It("Returns nil and an error", func() {
+				c, err := classifiers.NewRandomForest(forestCfg)
+				Expect(err).To(HaveOccurred())
+				Expect(c).To(BeNil())
+			})
+		})
+	})
+
+	Describe("Train", func() {
+		var single *classifiers.DataSet
+
+		BeforeEach(func() {
+			ds, err := classifiers.FromCSVFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			// Too few records for the split to leave any for training
+			single, err = classifiers.NewDataSet(ds.ClassNames, ds.AttributeNames, ds.Records[:1])
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		When("The data leaves no training records", func() {
+			It("Returns an error and leaves the model untrained", func() {
+				Expect(rfc.TrainFromDataset(single, cfg)).To(MatchError(ContainSubstring("no training records")))
+				Expect(rfc.RawData).To(BeNil())
+				Expect(rfc.TrainingData).To(BeNil())
+				_, err := rfc.Test()
+				Expect(err).To(HaveOccurred())
+			})
+
+			When("The model has already been trained", func() {
+				JustBeforeEach(func() {
+					Expect(rfc.TrainFromCSVFile(path, cfg)).To(Succeed())
+				})
+
+				It("Returns an error and leaves the model unchanged", func() {
+					trainingData := rfc.TrainingData
+					before, err := rfc.Test()
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(rfc.TrainFromDataset(single, cfg)).To(MatchError(ContainSubstring("no training records")))
+					Expect(rfc.TrainingData).To(BeIdenticalTo(trainingData))
+					after, err := rfc.Test()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(after).To(Equal(before))
+				})
+			})
+		})
+	})
+
+	Describe("Test", func() {
+		When("The model has been trained", func() {
+			JustBeforeEach(func() {
+				Expect(rfc.TrainFromCSVFile(path, cfg)).NotTo(HaveOccurred())
+			})
+
+			It("Aggregates the votes of the trees", func() {
+				results, err := rfc.Test()
+				Expect(err).NotTo(HaveOccurred())
+				for _, res := range results {
+					Expect(res.Votes).To(BeNumerically("<=", forestCfg.Trees))
+					Expect(res.Probability).To(Equal(float64(res.Votes) / float64(forestCfg.Trees)))
+					total := 0.0
+					for _, p := range res.Probabilities {
+						total += p
+					}
+					Expect(total).To(BeNumerically("~", 1.0))
+				}
+			})
+
+			It("Classifies most of the testing records correctly", func() {
+				results, _ := rfc.Test()
+				Expect(results.Analyze().Accuracy).To(BeNumerically(">", 0.85))
+			})
+
+			It("Is reproducible given the same seed", func() {
+				first, _ := rfc.Test()
+				Expect(rfc.Retrain(cfg)).NotTo(HaveOccurred())
+				second, _ := rfc.Test()
+				Expect(second).To(Equal(first))
+			})
+		})
+
+		When("The model has not been trained", func() {
+			It("Returns an error", func() {
+				_, err := rfc.Test()
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("OutOfBagAccuracy", func() {
+		JustBeforeEach(func() {
+			Expect(rfc.TrainFromCSVFile(path, cfg)).NotTo(HaveOccurred())
+		})
+
+		It("Estimates the accuracy from the records left out of each bootstrap sample", func() {
+			Expect(rfc.OutOfBagAccuracy()).To(BeNumerically(">", 0.85))
+			Expect(rfc.OutOfBagAccuracy()).To(BeNumerically("<=", 1.0))
+		})
+	})
+
+	Describe("AttributeImportances", func() {
+		JustBeforeEach(func() {
+			Expect(rfc.TrainFromCSVFile(path, cfg)).NotTo(HaveOccurred())
+		})
+
+		It("Reports an importance for every attribute", func() {
+			importances := rfc.AttributeImportances()
+			Expect(importances).To(HaveLen(len(rfc.TrainingData.AttributeNames)))
+			total := 0.0
+			for _, importance := range importances {
+				total += importance
+			}
+			Expect(total).To(BeNumerically("~", 1.0))
+		})
+
+		It("Ranks the petal measurements above the sepal measurements", func() {
+			importances := rfc.AttributeImportances()
+			Expect(importances["petal-length"] + importances["petal-width"]).To(BeNumerically(">", importances["sepal-length"]+importances["sepal-width"]))
+		})
+	})
+})
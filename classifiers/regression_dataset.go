@@ -0,0 +1,134 @@
+package classifiers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ScarletTanager/wyvern"
+)
+
+// RegressionDataSet is the regression counterpart of DataSet: each record has a continuous
+// target value rather than a class
+type RegressionDataSet struct {
+	// TargetName names the value being predicted
+	TargetName     string             `json:"target"`
+	AttributeNames []string           `json:"attributes"`
+	Records        []RegressionRecord `json:"data"`
+}
+
+type RegressionRecord struct {
+	Target          float64                `json:"target"`
+	AttributeValues wyvern.Vector[float64] `json:"values"`
+}
+
+func NewRegressionDataSet(target string, attributes []string, data []RegressionRecord) (*RegressionDataSet, error) {
+	for i, r := range data {
+		if len(r.AttributeValues) != len(attributes) {
+			return nil, fmt.Errorf("Record %d has %d attribute values, expected %d", i, len(r.AttributeValues), len(attributes))
+		}
+
+		if math.IsNaN(r.Target) || math.IsInf(r.Target, 0) {
+			return nil, fmt.Errorf("Record %d has an invalid target %f", i, r.Target)
+		}
+	}
+
+	return &RegressionDataSet{
+		TargetName:     target,
+		AttributeNames: attributes,
+		Records:        data,
+	}, nil
+}
+
+func RegressionFromJSON(dsJson []byte) (*RegressionDataSet, error) {
+	var ds RegressionDataSet
+	err := json.Unmarshal(dsJson, &ds)
+	if err != nil {
+		return nil, fmt.Errorf("While creating RegressionDataSet from JSON: %w", err)
+	}
+
+	return NewRegressionDataSet(ds.TargetName, ds.AttributeNames, ds.Records)
+}
+
+func RegressionFromJSONFile(path string) (*RegressionDataSet, error) {
+	jsonBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("When attempting to read JSON from file: %w", err)
+	}
+
+	return RegressionFromJSON(jsonBytes)
+}
+
+// RegressionFromCSV builds a RegressionDataSet from CSV data, in the same layout as for
+// FromCSV except that the last column holds the (numeric) target.  Returns nil and an error if
+// the data cannot be processed correctly.
+func RegressionFromCSV(dsCsv []byte) (*RegressionDataSet, error) {
+	s := bufio.NewScanner(bytes.NewReader(dsCsv))
+	lineNo := 1
+
+	// Parse header line
+	if !s.Scan() {
+		return nil, errors.New("Missing header line")
+	}
+
+	headerFields := strings.Split(s.Text(), ",")
+	attributeNames := headerFields[:len(headerFields)-1]
+	targetName := headerFields[len(headerFields)-1]
+
+	records := make([]RegressionRecord, 0)
+
+	for s.Scan() {
+		lineNo += 1
+		line := s.Bytes()
+		// We may expect the last line to be blank
+		if len(line) == 0 {
+			continue
+		}
+
+		lineFields := bytes.Split(line, []byte(","))
+		if len(lineFields) != len(headerFields) {
+			return nil, fmt.Errorf("Invalid data at line %d", lineNo)
+		}
+
+		values := make(wyvern.Vector[float64], len(lineFields))
+		for i, raw := range lineFields {
+			v, err := strconv.ParseFloat(string(raw), 64)
+			if err != nil {
+				return nil, fmt.Errorf("Unable to parse value %s, index %d, at line %d into float64", raw, i, lineNo)
+			}
+			values[i] = v
+		}
+
+		records = append(records, RegressionRecord{
+			Target:          values[len(values)-1],
+			AttributeValues: values[:len(values)-1],
+		})
+	}
+
+	return NewRegressionDataSet(targetName, attributeNames, records)
+}
+
+// RegressionFromCSVFile reads the CSV-formatted file and creates a RegressionDataSet from it.
+func RegressionFromCSVFile(path string) (*RegressionDataSet, error) {
+	csvBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("When attempting to read CSV file: %w", err)
+	}
+
+	return RegressionFromCSV(csvBytes)
+}
+
+// Split divides the dataset into training and test data, in the same way as DataSet.Split.
+// This does not modify the original RegressionDataSet.
+func (ds *RegressionDataSet) Split(cfg *DataSplitConfig) (*RegressionDataSet, *RegressionDataSet, error) {
+	trainingRecords, testRecords := splitRecords(ds.Records, cfg)
+	training, _ := NewRegressionDataSet(ds.TargetName, ds.AttributeNames, trainingRecords)
+	test, _ := NewRegressionDataSet(ds.TargetName, ds.AttributeNames, testRecords)
+	return training, test, nil
+}
@@ -0,0 +1,56 @@
+package classifiers_test
+
+import (
+	"math"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ScarletTanager/basilisk/classifiers"
+	"github.com/ScarletTanager/wyvern"
+)
+
+var _ = Describe("RegressionDataSet", func() {
+	Describe("NewRegressionDataSet", func() {
+		It("Rejects records with the wrong number of attribute values", func() {
+			_, err := classifiers.NewRegressionDataSet("price", []string{"size", "age"}, []classifiers.RegressionRecord{
+				{Target: 1, AttributeValues: wyvern.Vector[float64]{1}},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Rejects records with a target which is not a number", func() {
+			_, err := classifiers.NewRegressionDataSet("price", []string{"size"}, []classifiers.RegressionRecord{
+				{Target: math.NaN(), AttributeValues: wyvern.Vector[float64]{1}},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("RegressionFromCSV", func() {
+		It("Reads the last column as the target", func() {
+			ds, err := classifiers.RegressionFromCSVFile("../fixtures/plane.csv")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ds.TargetName).To(Equal("z"))
+			Expect(ds.AttributeNames).To(Equal([]string{"x", "y"}))
+			Expect(ds.Records).To(HaveLen(40))
+			Expect(ds.Records[1]).To(Equal(classifiers.RegressionRecord{Target: 3, AttributeValues: wyvern.Vector[float64]{0, 1}}))
+		})
+
+		It("Rejects values which are not numbers", func() {
+			_, err := classifiers.RegressionFromCSV([]byte("x,z\n1,cheap\n"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Split", func() {
+		It("Divides the records between training and test data", func() {
+			ds, _ := classifiers.RegressionFromCSVFile("../fixtures/plane.csv")
+			training, test, err := ds.Split(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(training.Records).To(HaveLen(30))
+			Expect(test.Records).To(HaveLen(10))
+			Expect(append(training.Records, test.Records...)).To(ConsistOf(ds.Records))
+		})
+	})
+})
@@ -0,0 +1,247 @@
+// Package crossvalidation estimates how well a classifier generalizes by training and testing
+// it on each of several divisions (folds) of a dataset.
+package crossvalidation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ScarletTanager/basilisk/classifiers"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// The records are dealt out to the folds in turn
+	Method_KFold = "kfold"
+	// The records of each class are dealt out to the folds in turn, so that every fold has
+	// (nearly) the same share of each class
+	Method_Stratified = "stratified"
+	// Each record is a fold of its own, so the classifier is trained once per record
+	Method_LeaveOneOut = "leave_one_out"
+
+	DEFAULT_FOLDS = 5
+
+	// The metrics summarized over the folds
+	Metric_Accuracy            = "accuracy"
+	Metric_BalancedAccuracy    = "balanced_accuracy"
+	Metric_MacroF1             = "macro_f1"
+	Metric_WeightedF1          = "weighted_f1"
+	Metric_CohensKappa         = "cohens_kappa"
+	Metric_MatthewsCorrelation = "matthews_correlation"
+	Metric_LogLoss             = "log_loss"
+	Metric_BrierScore          = "brier_score"
+)
+
+// Config selects how the dataset is divided into folds
+type Config struct {
+	// Method is one of the Method_ values, empty means Method_KFold
+	Method string `json:"method,omitempty"`
+	// Folds is the number of folds - 0 means DEFAULT_FOLDS.  It is ignored by Method_LeaveOneOut.
+	Folds int `json:"folds,omitempty"`
+}
+
+// NewClassifier creates the (untrained) classifier for a fold
+type NewClassifier func() (classifiers.Classifier, error)
+
+// FoldResult holds the metrics of the classifier trained and tested on a single fold
+type FoldResult struct {
+	TrainingCount int                `json:"training"`
+	TestCount     int                `json:"test"`
+	Metrics       map[string]float64 `json:"metrics"`
+}
+
+// Summary is the mean and (population) standard deviation of a metric over the folds
+type Summary struct {
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"std_dev"`
+}
+
+// Report holds the results of a cross-validation
+type Report struct {
+	Method string       `json:"method"`
+	Folds  []FoldResult `json:"folds"`
+	// Metrics summarizes each of the Metric_ values over the folds.  Metrics which compare
+	// classes (such as Cohen's kappa) mean little for folds of only a few records - with
+	// leave one out, use the pooled analysis instead.
+	Metrics map[string]Summary `json:"metrics"`
+	// Pooled analyzes the test results of all of the folds together, so that every record
+	// of the dataset is tested once
+	Pooled classifiers.TestResultsAnalysis `json:"pooled"`
+}
+
+// Run cross-validates the classifiers created by newClassifier on the dataset, stopping early
+// (and returning the context's error) if the context is cancelled
+func Run(ctx context.Context, ds *classifiers.DataSet, newClassifier NewClassifier, cfg Config) (*Report, error) {
+	trainingSets, testSets, err := Folds(ds, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Method:  cfg.Method,
+		Folds:   make([]FoldResult, len(trainingSets)),
+		Metrics: make(map[string]Summary),
+	}
+	if report.Method == "" {
+		report.Method = Method_KFold
+	}
+
+	pooled := make(classifiers.TestResults, 0, len(ds.Records))
+	for f := range trainingSets {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		cl, err := newClassifier()
+		if err != nil {
+			return nil, fmt.Errorf("Unable to create the classifier for fold %d: %w", f, err)
+		}
+
+		// Train on the training set, and test on the test set
+		training, test := trainingSets[f].Records, testSets[f].Records
+		foldData, _ := classifiers.NewDataSet(ds.ClassNames, ds.AttributeNames, append(slices.Clip(training), test...))
+		if err = cl.TrainFromDataset(foldData, &classifiers.DataSplitConfig{
+			Method:        classifiers.SplitSequential,
+			TrainingCount: len(training),
+		}); err != nil {
+			return nil, fmt.Errorf("Unable to train the classifier for fold %d: %w", f, err)
+		}
+
+		results, err := cl.TestContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		report.Folds[f] = FoldResult{
+			TrainingCount: len(training),
+			TestCount:     len(test),
+			Metrics:       metrics(results.AnalyzeClasses(ds.ClassNames)),
+		}
+		pooled = append(pooled, results...)
+	}
+
+	for name := range report.Folds[0].Metrics {
+		values := make([]float64, len(report.Folds))
+		for f, fold := range report.Folds {
+			values[f] = fold.Metrics[name]
+		}
+		report.Metrics[name] = summarize(values)
+	}
+
+	report.Pooled = pooled.AnalyzeClasses(ds.ClassNames)
+	return report, nil
+}
+
+// Folds divides the dataset into folds as configured, returning a training and a test
+// DataSet for each fold.  The test DataSets hold each record exactly once.
+func Folds(ds *classifiers.DataSet, cfg Config) ([]*classifiers.DataSet, []*classifiers.DataSet, error) {
+	folds := cfg.Folds
+	if folds == 0 {
+		folds = DEFAULT_FOLDS
+	}
+
+	switch cfg.Method {
+	case "", Method_KFold:
+		return ds.KFold(folds)
+	case Method_Stratified:
+		return stratifiedFolds(ds, folds)
+	case Method_LeaveOneOut:
+		return ds.KFold(len(ds.Records))
+	}
+
+	return nil, nil, fmt.Errorf("Unknown cross-validation method %s", cfg.Method)
+}
+
+// stratifiedFolds deals the records of each class out to the folds in turn, carrying on from
+// where the previous class left off so that the folds are (nearly) the same size
+func stratifiedFolds(ds *classifiers.DataSet, k int) ([]*classifiers.DataSet, []*classifiers.DataSet, error) {
+	if k < 2 || k > len(ds.Records) {
+		return nil, nil, fmt.Errorf("Unable to divide %d records into %d folds", len(ds.Records), k)
+	}
+
+	fold := make([]int, len(ds.Records))
+	next := 0
+	for class := range ds.ClassNames {
+		for i, r := range ds.Records {
+			if r.Class == class {
+				fold[i] = next % k
+				next++
+			}
+		}
+	}
+
+	training := make([]*classifiers.DataSet, k)
+	test := make([]*classifiers.DataSet, k)
+	for f := 0; f < k; f++ {
+		trainingRecords := make([]classifiers.Record, 0, len(ds.Records)-len(ds.Records)/k)
+		testRecords := make([]classifiers.Record, 0, len(ds.Records)/k+1)
+		for i, r := range ds.Records {
+			if fold[i] == f {
+				testRecords = append(testRecords, r)
+			} else {
+				trainingRecords = append(trainingRecords, r)
+			}
+		}
+
+		training[f], _ = classifiers.NewDataSet(ds.ClassNames, ds.AttributeNames, trainingRecords)
+		test[f], _ = classifiers.NewDataSet(ds.ClassNames, ds.AttributeNames, testRecords)
+	}
+
+	return training, test, nil
+}
+
+// Like returns a NewClassifier which creates untrained classifiers with the same
+// configuration as cl
+func Like(cl classifiers.Classifier) (NewClassifier, error) {
+	switch c := cl.(type) {
+	case *classifiers.KNearestNeighborClassifier:
+		// Read under the model's lock, as it may be retrained while it is cross-validated
+		cfg := c.Config().(classifiers.KNearestNeighborClassifierConfig)
+		return func() (classifiers.Classifier, error) { return classifiers.NewKnnFromConfig(cfg) }, nil
+	case *classifiers.NaiveBayesClassifier:
+		cfg := c.Configuration
+		return func() (classifiers.Classifier, error) { return classifiers.NewNaiveBayes(cfg.VarianceSmoothing) }, nil
+	case *classifiers.DecisionTreeClassifier:
+		cfg := c.Configuration
+		return func() (classifiers.Classifier, error) { return classifiers.NewDecisionTree(cfg) }, nil
+	case *classifiers.RandomForestClassifier:
+		cfg := c.Configuration
+		return func() (classifiers.Classifier, error) { return classifiers.NewRandomForest(cfg) }, nil
+	case *classifiers.LogisticRegressionClassifier:
+		cfg := c.Configuration
+		return func() (classifiers.Classifier, error) { return classifiers.NewLogisticRegression(cfg) }, nil
+	case nil:
+		return nil, errors.New("Cannot cross-validate a nil classifier")
+	}
+
+	return nil, fmt.Errorf("Cannot cross-validate %s models", cl.Type())
+}
+
+func metrics(analysis classifiers.TestResultsAnalysis) map[string]float64 {
+	return map[string]float64{
+		Metric_Accuracy:            analysis.Accuracy,
+		Metric_BalancedAccuracy:    analysis.Agreement.BalancedAccuracy,
+		Metric_MacroF1:             analysis.MacroAverage.F1,
+		Metric_WeightedF1:          analysis.WeightedAverage.F1,
+		Metric_CohensKappa:         analysis.Agreement.CohensKappa,
+		Metric_MatthewsCorrelation: analysis.Agreement.MatthewsCorrelation,
+		Metric_LogLoss:             analysis.Calibration.LogLoss,
+		Metric_BrierScore:          analysis.Calibration.BrierScore,
+	}
+}
+
+func summarize(values []float64) Summary {
+	var s Summary
+	for _, v := range values {
+		s.Mean += v / float64(len(values))
+	}
+
+	for _, v := range values {
+		s.StdDev += (v - s.Mean) * (v - s.Mean) / float64(len(values))
+	}
+	s.StdDev = math.Sqrt(s.StdDev)
+
+	return s
+}
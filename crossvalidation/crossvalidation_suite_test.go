@@ -0,0 +1,13 @@
+package crossvalidation_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCrossvalidation(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Crossvalidation Suite")
+}
@@ -0,0 +1,209 @@
+package crossvalidation_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/ScarletTanager/basilisk/classifiers"
+	"github.com/ScarletTanager/basilisk/crossvalidation"
+)
+
+var _ = Describe("Crossvalidation", func() {
+	var (
+		ds            *classifiers.DataSet
+		cfg           crossvalidation.Config
+		newClassifier crossvalidation.NewClassifier
+	)
+
+	BeforeEach(func() {
+		var err error
+		ds, err = classifiers.FromCSVFile("../fixtures/students.csv")
+		Expect(err).NotTo(HaveOccurred())
+
+		cfg = crossvalidation.Config{}
+		newClassifier = func() (classifiers.Classifier, error) {
+			return classifiers.NewKnn(1, classifiers.DistanceMethod_Euclidean)
+		}
+	})
+
+	Describe("Folds", func() {
+		It("Tests every record exactly once", func() {
+			for _, method := range []string{crossvalidation.Method_KFold, crossvalidation.Method_Stratified, crossvalidation.Method_LeaveOneOut} {
+				cfg.Method = method
+				training, test, err := crossvalidation.Folds(ds, cfg)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(training).To(HaveLen(len(test)))
+
+				tested := make([]classifiers.Record, 0, len(ds.Records))
+				for f := range test {
+					Expect(len(training[f].Records) + len(test[f].Records)).To(Equal(len(ds.Records)))
+					tested = append(tested, test[f].Records...)
+				}
+				Expect(tested).To(ConsistOf(ds.Records))
+			}
+		})
+
+		It("Uses the default number of folds", func() {
+			training, test, err := crossvalidation.Folds(ds, cfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(training).To(HaveLen(crossvalidation.DEFAULT_FOLDS))
+			Expect(test).To(HaveLen(crossvalidation.DEFAULT_FOLDS))
+		})
+
+		When("Stratifying", func() {
+			BeforeEach(func() {
+				cfg.Method = crossvalidation.Method_Stratified
+				cfg.Folds = 4
+			})
+
+			It("Puts one record of each class in every fold", func() {
+				_, test, err := crossvalidation.Folds(ds, cfg)
+				Expect(err).NotTo(HaveOccurred())
+				for _, fold := range test {
+					classes := make([]int, 0, len(fold.Records))
+					for _, r := range fold.Records {
+						classes = append(classes, r.Class)
+					}
+					Expect(classes).To(ConsistOf(0, 1, 2))
+				}
+			})
+		})
+
+		When("Leaving one out", func() {
+			BeforeEach(func() {
+				cfg.Method = crossvalidation.Method_LeaveOneOut
+				cfg.Folds = 3
+			})
+
+			It("Has a fold for each record, ignoring the number of folds", func() {
+				_, test, err := crossvalidation.Folds(ds, cfg)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(test).To(HaveLen(len(ds.Records)))
+				for _, fold := range test {
+					Expect(fold.Records).To(HaveLen(1))
+				}
+			})
+		})
+
+		It("Returns an error for an unknown method", func() {
+			cfg.Method = "bootstrap"
+			_, _, err := crossvalidation.Folds(ds, cfg)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Returns an error for too many or too few folds", func() {
+			for _, method := range []string{crossvalidation.Method_KFold, crossvalidation.Method_Stratified} {
+				cfg.Method = method
+				for _, folds := range []int{1, -2, len(ds.Records) + 1} {
+					cfg.Folds = folds
+					_, _, err := crossvalidation.Folds(ds, cfg)
+					Expect(err).To(HaveOccurred())
+				}
+			}
+		})
+	})
+
+	Describe("Run", func() {
+		It("Trains and tests a classifier on each fold", func() {
+			cfg.Folds = 3
+			report, err := crossvalidation.Run(context.Background(), ds, newClassifier, cfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Method).To(Equal(crossvalidation.Method_KFold))
+			Expect(report.Folds).To(HaveLen(3))
+			for _, fold := range report.Folds {
+				Expect(fold.TrainingCount).To(Equal(8))
+				Expect(fold.TestCount).To(Equal(4))
+				Expect(fold.Metrics).To(HaveKeyWithValue(crossvalidation.Metric_Accuracy, 1.0))
+			}
+		})
+
+		It("Summarizes each metric over the folds", func() {
+			report, err := crossvalidation.Run(context.Background(), ds, newClassifier, cfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Metrics).To(HaveLen(8))
+			Expect(report.Metrics).To(HaveKeyWithValue(crossvalidation.Metric_Accuracy, crossvalidation.Summary{Mean: 1, StdDev: 0}))
+		})
+
+		It("Computes the standard deviation over the folds", func() {
+			// With only 3 records of each class in training, 3 neighbors misclassify some
+			newClassifier = func() (classifiers.Classifier, error) {
+				return classifiers.NewKnn(3, classifiers.DistanceMethod_Euclidean)
+			}
+			cfg.Method = crossvalidation.Method_Stratified
+			cfg.Folds = 4
+
+			report, err := crossvalidation.Run(context.Background(), ds, newClassifier, cfg)
+			Expect(err).NotTo(HaveOccurred())
+
+			var mean, variance float64
+			for _, fold := range report.Folds {
+				mean += fold.Metrics[crossvalidation.Metric_Accuracy] / 4
+			}
+			for _, fold := range report.Folds {
+				d := fold.Metrics[crossvalidation.Metric_Accuracy] - mean
+				variance += d * d / 4
+			}
+
+			summary := report.Metrics[crossvalidation.Metric_Accuracy]
+			Expect(summary.Mean).To(BeNumerically("~", mean))
+			Expect(summary.StdDev * summary.StdDev).To(BeNumerically("~", variance))
+		})
+
+		It("Pools the results of every fold", func() {
+			cfg.Method = crossvalidation.Method_LeaveOneOut
+			report, err := crossvalidation.Run(context.Background(), ds, newClassifier, cfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Folds).To(HaveLen(len(ds.Records)))
+			Expect(report.Pooled.ResultCount).To(Equal(len(ds.Records)))
+			Expect(report.Pooled.ConfusionMatrix.Labels).To(Equal(ds.ClassNames))
+			Expect(report.Pooled.Agreement.CohensKappa).To(BeNumerically("~", 1))
+		})
+
+		It("Does not modify the dataset", func() {
+			records := append([]classifiers.Record{}, ds.Records...)
+			_, err := crossvalidation.Run(context.Background(), ds, newClassifier, cfg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ds.Records).To(Equal(records))
+		})
+
+		It("Stops when the context is cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := crossvalidation.Run(ctx, ds, newClassifier, cfg)
+			Expect(err).To(MatchError(context.Canceled))
+		})
+
+		It("Returns an error if the classifier cannot be created", func() {
+			newClassifier = func() (classifiers.Classifier, error) {
+				return classifiers.NewKnn(-1, classifiers.DistanceMethod_Euclidean)
+			}
+			_, err := crossvalidation.Run(context.Background(), ds, newClassifier, cfg)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Like", func() {
+		It("Creates untrained classifiers configured like the original", func() {
+			original, err := classifiers.NewDecisionTree(classifiers.DecisionTreeClassifierConfig{MaxDepth: 2})
+			Expect(err).NotTo(HaveOccurred())
+
+			newClassifier, err = crossvalidation.Like(original)
+			Expect(err).NotTo(HaveOccurred())
+
+			cl, err := newClassifier()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cl).NotTo(BeIdenticalTo(original))
+			Expect(cl.Config()).To(Equal(original.Config()))
+
+			training, _ := cl.Data()
+			Expect(training).To(BeNil())
+		})
+
+		It("Returns an error for a nil classifier", func() {
+			_, err := crossvalidation.Like(nil)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})